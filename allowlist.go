@@ -1,20 +1,21 @@
 package mcmsmpgo
 
 import (
+	"context"
 	"github.com/CycleZero/mc-msmp-go/dto/subdto"
 )
 
-func (c *MsmpClient) AllowlistSet(id string, name string) {
-	param := []subdto.PlayerDto{
+func (c *MsmpClient) AllowlistSet(id string, name string) error {
+	param, err := subdto.NormalizeAll([]subdto.PlayerDto{
 		subdto.PlayerDto{
 			Id:   id,
 			Name: name,
 		},
-	}
-	err := c.SendRequest("minecraft:allowlist/set", param)
+	})
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:allowlist/set", param)
 }
 
 func (c *MsmpClient) Allowlist() {
@@ -24,26 +25,26 @@ func (c *MsmpClient) Allowlist() {
 	}
 }
 
-func (c *MsmpClient) AllowlistAdd(id string, name string) {
-	param := subdto.PlayerDto{
+func (c *MsmpClient) AllowlistAdd(id string, name string) error {
+	param, err := subdto.PlayerDto{
 		Id:   id,
 		Name: name,
-	}
-	err := c.SendRequest("minecraft:allowlist/add", param)
+	}.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:allowlist/add", param)
 }
 
-func (c *MsmpClient) AllowlistRemove(id string, name string) {
-	param := subdto.PlayerDto{
+func (c *MsmpClient) AllowlistRemove(id string, name string) error {
+	param, err := subdto.PlayerDto{
 		Id:   id,
 		Name: name,
-	}
-	err := c.SendRequest("minecraft:allowlist/remove", param)
+	}.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:allowlist/remove", param)
 }
 
 func (c *MsmpClient) AllowlistClear() {
@@ -52,3 +53,21 @@ func (c *MsmpClient) AllowlistClear() {
 		return
 	}
 }
+
+// GetAllowlist 获取白名单列表并等待结果
+func (c *MsmpClient) GetAllowlist(ctx context.Context) ([]subdto.PlayerDto, error) {
+	return Call[[]subdto.PlayerDto](ctx, c, "minecraft:allowlist", nil)
+}
+
+// SetAllowlist 用players替换整个白名单，返回服务端设置后的白名单
+// 玩家Id会被标准化，存在无效Id时不发送请求并返回错误
+func (c *MsmpClient) SetAllowlist(ctx context.Context, players []subdto.PlayerDto) ([]subdto.PlayerDto, error) {
+	players, err := subdto.NormalizeAll(players)
+	if err != nil {
+		return nil, err
+	}
+	if players == nil {
+		players = []subdto.PlayerDto{}
+	}
+	return Call[[]subdto.PlayerDto](ctx, c, "minecraft:allowlist/set", players)
+}
@@ -0,0 +1,343 @@
+package mcmsmpgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/ecode"
+	"io"
+	"sort"
+	"time"
+)
+
+// BackupVersion 当前备份文档的格式版本
+const BackupVersion = 1
+
+// BackupSection 备份文档中的一个部分
+type BackupSection string
+
+const (
+	SectionAllowlist BackupSection = "allowlist"
+	SectionBans      BackupSection = "bans"
+	SectionIpBans    BackupSection = "ipBans"
+	SectionOperators BackupSection = "operators"
+	SectionGamerules BackupSection = "gamerules"
+	SectionSettings  BackupSection = "settings"
+)
+
+// AllSections 所有备份部分
+var AllSections = []BackupSection{
+	SectionAllowlist,
+	SectionBans,
+	SectionIpBans,
+	SectionOperators,
+	SectionGamerules,
+	SectionSettings,
+}
+
+// BackupDocument 服务端管理数据的完整快照
+type BackupDocument struct {
+	Version   int                        `json:"version"`
+	CreatedAt time.Time                  `json:"createdAt"`
+	Allowlist []subdto.PlayerDto         `json:"allowlist"`
+	Bans      []subdto.UserBanDto        `json:"bans"`
+	IpBans    []subdto.IpBanDTO          `json:"ipBans"`
+	Operators []subdto.OperatorDto       `json:"operators"`
+	Gamerules []subdto.TypedRule         `json:"gamerules"`
+	Settings  map[string]json.RawMessage `json:"settings"`
+}
+
+// Backup 并发获取白名单、封禁、封禁IP、管理员、游戏规则和所有服务端设置，生成快照
+// 服务端不支持的设置会被跳过
+func (c *MsmpClient) Backup(ctx context.Context) (*BackupDocument, error) {
+	doc := &BackupDocument{
+		Version:   BackupVersion,
+		CreatedAt: time.Now().UTC(),
+		Settings:  make(map[string]json.RawMessage),
+	}
+
+	lists := []struct {
+		method string
+		out    interface{}
+	}{
+		{"minecraft:allowlist", &doc.Allowlist},
+		{"minecraft:bans", &doc.Bans},
+		{"minecraft:ip_bans", &doc.IpBans},
+		{"minecraft:operators", &doc.Operators},
+		{"minecraft:gamerules", &doc.Gamerules},
+	}
+	listCalls := make([]*PendingCall, 0, len(lists))
+	settingCalls := make(map[string]*PendingCall, len(ServerSettingNames))
+	defer func() {
+		for _, p := range listCalls {
+			p.Cancel()
+		}
+		for _, p := range settingCalls {
+			p.Cancel()
+		}
+	}()
+
+	for _, l := range lists {
+		p, err := c.Go(l.method, nil)
+		if err != nil {
+			return nil, err
+		}
+		listCalls = append(listCalls, p)
+	}
+	for _, name := range ServerSettingNames {
+		p, err := c.Go("minecraft:serversettings/"+name, nil)
+		if err != nil {
+			return nil, err
+		}
+		settingCalls[name] = p
+	}
+
+	for i, l := range lists {
+		if err := listCalls[i].Decode(ctx, l.out); err != nil {
+			return nil, fmt.Errorf("backup: %w", err)
+		}
+	}
+	for name, p := range settingCalls {
+		var raw json.RawMessage
+		err := p.Decode(ctx, &raw)
+		if isMethodNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: %w", err)
+		}
+		// 没有值的设置不写入快照，否则恢复时会把 null 发送给服务端
+		if isNullJSON(raw) {
+			continue
+		}
+		doc.Settings[name] = raw
+	}
+	// 服务端返回 null 时按空列表保存
+	if doc.Allowlist == nil {
+		doc.Allowlist = []subdto.PlayerDto{}
+	}
+	if doc.Bans == nil {
+		doc.Bans = []subdto.UserBanDto{}
+	}
+	if doc.IpBans == nil {
+		doc.IpBans = []subdto.IpBanDTO{}
+	}
+	if doc.Operators == nil {
+		doc.Operators = []subdto.OperatorDto{}
+	}
+	if doc.Gamerules == nil {
+		doc.Gamerules = []subdto.TypedRule{}
+	}
+	return doc, nil
+}
+
+// isMethodNotFound 错误是否为服务端不支持该方法
+func isMethodNotFound(err error) bool {
+	var rpcErr *dto.MsmpResponseError
+	return errors.As(err, &rpcErr) && rpcErr.Code == ecode.METHOD_NOT_FOUND
+}
+
+// isNullJSON 值是否为空或 null
+func isNullJSON(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// WriteBackup 将备份文档写为JSON
+func WriteBackup(w io.Writer, doc *BackupDocument) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadBackup 读取备份文档，不支持更高版本的文档
+func ReadBackup(r io.Reader) (*BackupDocument, error) {
+	var doc BackupDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Version <= 0 || doc.Version > BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", doc.Version)
+	}
+	return &doc, nil
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// 需要恢复的部分，为空时恢复全部
+	Sections []BackupSection
+	// 只计算差异，不修改服务端，用于预览
+	DryRun bool
+}
+
+func (o RestoreOptions) includes(section BackupSection) bool {
+	if len(o.Sections) == 0 {
+		return true
+	}
+	for _, s := range o.Sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
+
+// RestoreReport 恢复时各部分的差异，未恢复的部分为nil
+type RestoreReport struct {
+	Allowlist *ReconcileReport[subdto.PlayerDto]
+	Bans      *ReconcileReport[subdto.UserBanDto]
+	IpBans    *ReconcileReport[subdto.IpBanDTO]
+	Operators *ReconcileReport[subdto.OperatorDto]
+	Gamerules []Change[subdto.TypedRule]
+	Settings  map[string]Change[json.RawMessage]
+	// 目标服务端不支持而跳过的设置，按名称排序
+	UnsupportedSettings []string
+	DryRun              bool
+}
+
+// Restore 将备份文档应用到服务端
+// 列表部分通过 Reconcile 最小化修改，游戏规则与服务端设置只修改不同的项
+func (c *MsmpClient) Restore(ctx context.Context, doc *BackupDocument, opts RestoreOptions) (*RestoreReport, error) {
+	if doc.Version <= 0 || doc.Version > BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", doc.Version)
+	}
+	report := &RestoreReport{DryRun: opts.DryRun}
+	reconcileOpts := ReconcileOptions{DryRun: opts.DryRun}
+	var err error
+
+	if opts.includes(SectionAllowlist) {
+		if report.Allowlist, err = c.ReconcileAllowlist(ctx, doc.Allowlist, reconcileOpts); err != nil {
+			return report, err
+		}
+	}
+	if opts.includes(SectionBans) {
+		if report.Bans, err = c.ReconcileBans(ctx, doc.Bans, reconcileOpts); err != nil {
+			return report, err
+		}
+	}
+	if opts.includes(SectionIpBans) {
+		if report.IpBans, err = c.ReconcileIpBans(ctx, doc.IpBans, reconcileOpts); err != nil {
+			return report, err
+		}
+	}
+	if opts.includes(SectionOperators) {
+		if report.Operators, err = c.ReconcileOperators(ctx, doc.Operators, reconcileOpts); err != nil {
+			return report, err
+		}
+	}
+	if opts.includes(SectionGamerules) {
+		if report.Gamerules, err = c.restoreGamerules(ctx, doc.Gamerules, opts.DryRun); err != nil {
+			return report, err
+		}
+	}
+	if opts.includes(SectionSettings) {
+		if report.Settings, report.UnsupportedSettings, err = c.restoreSettings(ctx, doc.Settings, opts.DryRun); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// DiffGamerules 返回desired中与current值不同的规则，current中不存在的规则也视为不同
+// 没有差异时返回空切片而不是nil，以便与未恢复的部分区分
+func DiffGamerules(current, desired []subdto.TypedRule) []Change[subdto.TypedRule] {
+	live := make(map[string]subdto.TypedRule, len(current))
+	for _, r := range current {
+		live[r.Key] = r
+	}
+	changes := []Change[subdto.TypedRule]{}
+	for _, r := range desired {
+		before, ok := live[r.Key]
+		if ok && before.Value == r.Value {
+			continue
+		}
+		changes = append(changes, Change[subdto.TypedRule]{Before: before, After: r})
+	}
+	return changes
+}
+
+func (c *MsmpClient) restoreGamerules(ctx context.Context, desired []subdto.TypedRule, dryRun bool) ([]Change[subdto.TypedRule], error) {
+	current, err := c.GetGamerules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("restore gamerules: %w", err)
+	}
+	changes := DiffGamerules(current, desired)
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	rules := make([]subdto.TypedRule, 0, len(changes))
+	for _, ch := range changes {
+		rules = append(rules, ch.After)
+	}
+	if _, err := c.UpdateGamerules(ctx, rules); err != nil {
+		return changes, fmt.Errorf("restore gamerules: %w", err)
+	}
+	return changes, nil
+}
+
+// sameJSON 比较两个JSON值，忽略空白差异
+func sameJSON(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// DiffSettings 返回desired中与current值不同的服务端设置
+func DiffSettings(current, desired map[string]json.RawMessage) map[string]Change[json.RawMessage] {
+	changes := make(map[string]Change[json.RawMessage])
+	for name, value := range desired {
+		before, ok := current[name]
+		if ok && sameJSON(before, value) {
+			continue
+		}
+		changes[name] = Change[json.RawMessage]{Before: before, After: value}
+	}
+	return changes
+}
+
+// restoreSettings 修改与desired不同的服务端设置
+// 服务端不支持的设置和备份中没有值的设置会被跳过，前者通过unsupported返回
+func (c *MsmpClient) restoreSettings(ctx context.Context, desired map[string]json.RawMessage, dryRun bool) (changes map[string]Change[json.RawMessage], unsupported []string, err error) {
+	current := make(map[string]json.RawMessage, len(desired))
+	supported := make(map[string]json.RawMessage, len(desired))
+	for name, value := range desired {
+		if isNullJSON(value) {
+			continue
+		}
+		live, err := c.GetServerSetting(ctx, name)
+		if isMethodNotFound(err) {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("restore settings: %w", err)
+		}
+		current[name] = live
+		supported[name] = value
+	}
+	sort.Strings(unsupported)
+	changes = DiffSettings(current, supported)
+	if dryRun {
+		return changes, unsupported, nil
+	}
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := c.SetServerSetting(ctx, name, changes[name].After); err != nil {
+			return changes, unsupported, fmt.Errorf("restore settings: %w", err)
+		}
+	}
+	return changes, unsupported, nil
+}
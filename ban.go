@@ -1,12 +1,17 @@
 package mcmsmpgo
 
-import "github.com/CycleZero/mc-msmp-go/dto/subdto"
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"time"
+)
 
-func (c *MsmpClient) BansSet(bans []subdto.UserBanDto) {
-	err := c.SendRequest("minecraft:bans/set", bans)
+func (c *MsmpClient) BansSet(bans []subdto.UserBanDto) error {
+	bans, err := subdto.NormalizeAll(bans)
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:bans/set", bans)
 }
 
 func (c *MsmpClient) Bans() {
@@ -16,18 +21,20 @@ func (c *MsmpClient) Bans() {
 	}
 }
 
-func (c *MsmpClient) BansAdd(ban subdto.UserBanDto) {
-	err := c.SendRequest("minecraft:bans/add", ban)
+func (c *MsmpClient) BansAdd(ban subdto.UserBanDto) error {
+	ban, err := ban.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:bans/add", ban)
 }
 
-func (c *MsmpClient) BansRemove(player subdto.PlayerDto) {
-	err := c.SendRequest("minecraft:bans/remove", player)
+func (c *MsmpClient) BansRemove(player subdto.PlayerDto) error {
+	player, err := player.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:bans/remove", player)
 }
 
 func (c *MsmpClient) BansClear() {
@@ -36,3 +43,35 @@ func (c *MsmpClient) BansClear() {
 		return
 	}
 }
+
+// GetBans 获取封禁玩家列表并等待结果
+func (c *MsmpClient) GetBans(ctx context.Context) ([]subdto.UserBanDto, error) {
+	return Call[[]subdto.UserBanDto](ctx, c, "minecraft:bans", nil)
+}
+
+// SetBans 用bans替换整个封禁玩家列表，返回服务端设置后的列表
+// 玩家Id会被标准化，存在无效Id时不发送请求并返回错误
+func (c *MsmpClient) SetBans(ctx context.Context, bans []subdto.UserBanDto) ([]subdto.UserBanDto, error) {
+	bans, err := subdto.NormalizeAll(bans)
+	if err != nil {
+		return nil, err
+	}
+	if bans == nil {
+		bans = []subdto.UserBanDto{}
+	}
+	return Call[[]subdto.UserBanDto](ctx, c, "minecraft:bans/set", bans)
+}
+
+// BanFor 封禁玩家duration时长，duration<=0 表示永久封禁，返回服务端添加后的封禁列表
+func (c *MsmpClient) BanFor(ctx context.Context, player subdto.PlayerDto, duration time.Duration, reason string) ([]subdto.UserBanDto, error) {
+	player, err := player.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	ban := subdto.UserBanDto{
+		Expires: subdto.ExpiresIn(duration),
+		Player:  player,
+		Reason:  reason,
+	}
+	return Call[[]subdto.UserBanDto](ctx, c, "minecraft:bans/add", ban)
+}
@@ -0,0 +1,40 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto"
+)
+
+// CallRaw 调用任意方法并返回原始JSON结果
+// method 需带完整命名空间，例如 "minecraft:players" 或模组扩展的 "mymod:foo/bar"
+// params 可以是 dto.Positional、dto.Named 或单个位置参数，nil表示无参数
+// 服务端返回错误时，错误链中包含 *dto.MsmpResponseError
+func CallRaw(ctx context.Context, c *MsmpClient, method string, params interface{}) (json.RawMessage, error) {
+	response, err := c.call(ctx, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	raw, err := dto.RawResult(response)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	return raw, nil
+}
+
+// Call 调用任意方法并将结果解码为T
+func Call[T any](ctx context.Context, c *MsmpClient, method string, params interface{}) (T, error) {
+	var result T
+	raw, err := CallRaw(ctx, c, method, params)
+	if err != nil {
+		return result, err
+	}
+	if len(raw) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return result, fmt.Errorf("%s: failed to decode result: %w", method, err)
+	}
+	return result, nil
+}
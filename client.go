@@ -1,10 +1,14 @@
 package mcmsmpgo
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/CycleZero/mc-msmp-go/clock"
 	"github.com/CycleZero/mc-msmp-go/container"
 	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/ecode"
+	"github.com/CycleZero/mc-msmp-go/executor"
 	"github.com/CycleZero/mc-msmp-go/handler"
 	"github.com/CycleZero/mc-msmp-go/iface"
 	"github.com/gorilla/websocket"
@@ -18,6 +22,15 @@ type NewClientConfig struct {
 	Handler       func(*dto.MsmpRequest, dto.MsmpResponse)
 	Container     iface.MessageContainer
 	AutoReconnect bool
+	// 回调执行器，默认为每个回调启动一个goroutine；客户端不会关闭传入的执行器
+	Executor iface.CallbackExecutor
+	// SaveAndWait 等方法轮询服务端状态的间隔，默认为1秒
+	PollInterval time.Duration
+	// 连接在这段时间内没有收到任何数据（包括ping的pong回复）时视为断开，默认为60秒；
+	// 客户端每隔三分之一的时间发送一次ping，服务端不发送消息但连接正常时不会断开
+	ReadTimeout time.Duration
+	// ScheduleStop、SaveAndWait 等方法使用的时钟，也是 Roster、StatusPoller、Watchdog 的默认时钟，默认为 clock.Real
+	Clock clock.Clock
 }
 
 // MsmpClient WebSocket客户端结构
@@ -34,6 +47,9 @@ type MsmpClient struct {
 	// 互斥锁，保护连接状态
 	mutex sync.Mutex
 
+	// 写锁，保证同一时间只有一个goroutine写入连接
+	writeMutex sync.Mutex
+
 	// 消息处理函数
 	messageHandler func(dto.MsmpResponse)
 
@@ -46,15 +62,44 @@ type MsmpClient struct {
 	// 重连间隔
 	reconnectInterval time.Duration
 
+	// 等待服务端确认时轮询状态的间隔
+	pollInterval time.Duration
+
+	// 没有收到任何数据时视为断开的时间，也决定发送ping的间隔
+	readTimeout time.Duration
+
+	// 计时和倒计时使用的时钟
+	clock clock.Clock
+
 	// 请求ID计数器
 	requestID int
 
 	// 等待响应的请求映射
 	container iface.MessageContainer
 
+	// 回调执行器
+	executor iface.CallbackExecutor
+
+	// 由读协程直接执行回调的请求ID，这些回调不阻塞，不经过执行器
+	inlineRequests sync.Map
+
 	// 退出信号
 	done chan struct{}
 
+	// 当前连接的读协程退出时关闭
+	connLost chan struct{}
+
+	// 通知订阅，方法名 -> 订阅ID -> 处理函数，由 subscribeMutex 保护
+	subscriptions  map[string]map[int]func(*dto.MsmpNotification)
+	subscriptionID int
+	subscribeMutex sync.Mutex
+
+	// 进入维护模式前的状态，由 maintenanceMutex 保护
+	// maintenancePending 表示正在进入维护模式，此时不持有锁等待宽限期
+	maintenance        *MaintenanceState
+	maintenancePending bool
+	maintenanceMutex   sync.Mutex
+
 	Handler    func(*dto.MsmpRequest, dto.MsmpResponse)
 	AuthSecret string
 }
@@ -65,6 +110,10 @@ func NewMsmpClient(url, secret string, config *NewClientConfig) *MsmpClient {
 		Handler:       handler.DefaultHandler,
 		Container:     container.NewMapMessageContainer(),
 		AutoReconnect: true,
+		Executor:      executor.NewGoroutineExecutor(nil),
+		PollInterval:  time.Second,
+		ReadTimeout:   60 * time.Second,
+		Clock:         clock.Real{},
 	}
 	if config != nil {
 		if config.Handler != nil {
@@ -73,6 +122,18 @@ func NewMsmpClient(url, secret string, config *NewClientConfig) *MsmpClient {
 		if config.Container != nil {
 			c.Container = config.Container
 		}
+		if config.Executor != nil {
+			c.Executor = config.Executor
+		}
+		if config.PollInterval > 0 {
+			c.PollInterval = config.PollInterval
+		}
+		if config.ReadTimeout > 0 {
+			c.ReadTimeout = config.ReadTimeout
+		}
+		if config.Clock != nil {
+			c.Clock = config.Clock
+		}
 		c.AutoReconnect = config.AutoReconnect
 	}
 
@@ -83,12 +144,26 @@ func NewMsmpClient(url, secret string, config *NewClientConfig) *MsmpClient {
 		reconnectInterval: 5 * time.Second,
 		requestID:         0,
 		container:         c.Container,
+		executor:          c.Executor,
+		pollInterval:      c.PollInterval,
+		readTimeout:       c.ReadTimeout,
+		clock:             c.Clock,
 		done:              make(chan struct{}),
 		Handler:           c.Handler,
 		AuthSecret:        secret,
 	}
 }
 
+// Clock 返回客户端使用的时钟
+func (c *MsmpClient) Clock() clock.Clock {
+	return c.clock
+}
+
+// clockFor 返回处理ctx时使用的时钟：ctx携带时钟时（例如调度器执行的任务）使用它，否则使用客户端的时钟
+func (c *MsmpClient) clockFor(ctx context.Context) clock.Clock {
+	return clock.FromContext(ctx, c.clock)
+}
+
 // SetMessageHandler 设置消息处理函数
 func (c *MsmpClient) SetMessageHandler(handler func(dto.MsmpResponse)) {
 	c.messageHandler = handler
@@ -126,9 +201,15 @@ func (c *MsmpClient) Connect() error {
 
 	c.Conn = conn
 	c.connected = true
+	c.connLost = make(chan struct{})
+	// 收到pong说明连接仍然正常，即使服务端没有发送消息
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	})
 
 	// 启动读取消息的goroutine
-	go c.readMessages()
+	go c.readMessages(c.connLost)
+	go c.keepalive(conn, c.connLost)
 
 	// 启动自动重连的goroutine（如果启用）
 	if c.autoReconnect {
@@ -153,22 +234,23 @@ func (c *MsmpClient) Disconnect() error {
 	return c.Conn.Close()
 }
 
-// readMessages 读取来自服务器的消息
-func (c *MsmpClient) readMessages() {
+// readMessages 读取来自服务器的消息，退出时让所有等待响应的请求失败并关闭lost
+func (c *MsmpClient) readMessages(lost chan struct{}) {
+	defer close(lost)
+	defer c.failPending()
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			// 设置读取超时
-			err := c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+			// 设置读取超时，收到消息或pong时延长
+			err := c.Conn.SetReadDeadline(time.Now().Add(c.readTimeout))
 			if err != nil {
 				continue
 			}
 
 			_, message, err := c.Conn.ReadMessage()
 			if err != nil {
-				fmt.Println("Error reading message: %v", err)
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					log.Printf("Error reading message: %v", err)
 				}
@@ -179,6 +261,10 @@ func (c *MsmpClient) readMessages() {
 				return
 			}
 			//fmt.Println("Received message:", string(message))
+			if notification, ok := dto.ParseNotification(message); ok {
+				c.dispatchNotification(notification)
+				continue
+			}
 			// 解析响应
 			response, err := dto.ParseResponse(message)
 			if err != nil {
@@ -192,9 +278,62 @@ func (c *MsmpClient) readMessages() {
 				log.Printf("Error parsing response: %v", err)
 				continue
 			}
-			go p.Callback(p.Request, p.Response)
+			if _, inline := c.inlineRequests.LoadAndDelete(p.Id); inline {
+				p.Callback(p.Request, p.Response)
+				continue
+			}
+			c.executor.Execute(p)
+
+		}
+	}
+}
+
+// keepalive 定期发送ping，直到连接断开
+// 服务端的WebSocket层回复pong后延长读取期限，服务端主线程卡住、不再发送消息时连接仍保持，
+// 由看门狗等根据心跳判断服务端卡住，只有连pong也收不到时才视为连接断开
+func (c *MsmpClient) keepalive(conn *websocket.Conn, lost <-chan struct{}) {
+	interval := c.readTimeout / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+				return
+			}
+		case <-lost:
+			return
+		}
+	}
+}
 
+// failPending 连接断开后不会再收到响应，让所有等待响应的请求失败
+// PendingCall 以 ErrConnectionLost 结束，其他回调收到 ErrConnectionLost 对应的失败响应
+func (c *MsmpClient) failPending() {
+	pairs, err := c.container.GetWaitingRequests()
+	if err != nil {
+		log.Printf("Error listing waiting requests: %v", err)
+		return
+	}
+	for _, p := range pairs {
+		if c.container.CancelRequest(p.Id) != nil {
+			// 已经收到响应或被取消
+			continue
+		}
+		if _, inline := c.inlineRequests.LoadAndDelete(p.Id); inline {
+			// 内部回调约定nil响应表示连接断开
+			p.Callback(p.Request, nil)
+			continue
+		}
+		if p.Callback == nil {
+			continue
 		}
+		p.Response = &dto.MsmpResponseFailure{
+			JSONRPC: "2.0",
+			ID:      p.Id,
+			Error:   dto.MsmpResponseError{Code: ecode.INTERNAL_ERROR, Message: ErrConnectionLost.Error()},
+		}
+		c.executor.Execute(p)
 	}
 }
 
@@ -224,12 +363,24 @@ func (c *MsmpClient) reconnect() {
 	}
 }
 
-// SendRequest 发送请求并等待响应
+// SendRequest 发送请求，响应交由 Handler 处理
 func (c *MsmpClient) SendRequest(method string, params interface{}) error {
+	_, err := c.send(method, params, c.Handler, false)
+	return err
+}
+
+func (c *MsmpClient) SendRequestWithCallback(method string, params interface{}, callback func(*dto.MsmpRequest, dto.MsmpResponse)) error {
+	_, err := c.send(method, params, callback, false)
+	return err
+}
+
+// send 分配请求ID、登记回调并发送请求，返回已发送的请求
+// inline 为true时回调由读协程直接执行，仅用于不会阻塞的内部回调
+func (c *MsmpClient) send(method string, params interface{}, callback func(*dto.MsmpRequest, dto.MsmpResponse), inline bool) (*dto.MsmpRequest, error) {
 	c.mutex.Lock()
 	if !c.connected {
 		c.mutex.Unlock()
-		return fmt.Errorf("not connected to server")
+		return nil, fmt.Errorf("not connected to server")
 	}
 
 	// 增加请求ID
@@ -243,63 +394,57 @@ func (c *MsmpClient) SendRequest(method string, params interface{}) error {
 	// 发送请求
 	data, err := json.Marshal(request)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %v", err)
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
-	//fmt.Println("Send Request:" + string(data))
-	err = c.container.AddRequestWithHandler(&request, c.Handler)
+	if inline {
+		c.inlineRequests.Store(id, struct{}{})
+	}
+	err = c.container.AddRequestWithHandler(&request, callback)
 	if err != nil {
-		return err
+		c.inlineRequests.Delete(id)
+		return nil, err
+	}
+	// 登记之前连接已经断开时，failPending 可能已经执行过，不会再处理这个请求
+	if !c.IsConnected() {
+		c.inlineRequests.Delete(id)
+		_ = c.container.CancelRequest(id)
+		return nil, ErrConnectionLost
 	}
-	//c.mutex.Lock()
-	err = c.Conn.WriteMessage(websocket.TextMessage, data)
-	//c.mutex.Unlock()
+	err = c.writeMessage(data)
 	if err != nil {
-		err := c.container.CancelRequest(id)
-		if err != nil {
-			return err
+		c.inlineRequests.Delete(id)
+		if cancelErr := c.container.CancelRequest(id); cancelErr != nil {
+			return nil, cancelErr
 		}
-		return fmt.Errorf("failed to send request: %v", err)
+		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
 
-	return nil
-
+	return &request, nil
 }
 
-func (c *MsmpClient) SendRequestWithCallback(method string, params interface{}, callback func(*dto.MsmpRequest, dto.MsmpResponse)) error {
-	c.mutex.Lock()
-	if !c.connected {
-		c.mutex.Unlock()
-		return fmt.Errorf("not connected to server")
-	}
-
-	// 增加请求ID
-	c.requestID++
-	id := c.requestID
-
-	// 构造请求
-	request := dto.NewMsmpRequest(id, method, params)
-	c.mutex.Unlock()
-
-	// 发送请求
-	data, err := json.Marshal(request)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %v", err)
-	}
-	err = c.container.AddRequestWithHandler(&request, callback)
+// call 发送请求并阻塞等待响应，ctx结束时取消请求
+func (c *MsmpClient) call(ctx context.Context, method string, params interface{}) (dto.MsmpResponse, error) {
+	p, err := c.Go(method, params)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = c.Conn.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		err := c.container.CancelRequest(id)
-		if err != nil {
-			return err
+	select {
+	case <-p.Done:
+		if p.Response == nil {
+			return nil, p.Error
 		}
-		return fmt.Errorf("failed to send request: %v", err)
+		return p.Response, nil
+	case <-ctx.Done():
+		p.Cancel()
+		return nil, ctx.Err()
 	}
+}
 
-	return nil
-
+// writeMessage 串行写入连接，websocket连接不支持并发写
+func (c *MsmpClient) writeMessage(data []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.Conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // SendNotification 发送通知（不需要响应）
@@ -320,7 +465,7 @@ func (c *MsmpClient) SendNotification(method string, params interface{}) error {
 		return fmt.Errorf("failed to marshal notification: %v", err)
 	}
 
-	err = c.Conn.WriteMessage(websocket.TextMessage, data)
+	err = c.writeMessage(data)
 	if err != nil {
 		return fmt.Errorf("failed to send notification: %v", err)
 	}
@@ -328,6 +473,18 @@ func (c *MsmpClient) SendNotification(method string, params interface{}) error {
 	return nil
 }
 
+// ConnectionLost 返回当前连接断开时关闭的channel，未连接时返回已关闭的channel
+func (c *MsmpClient) ConnectionLost() <-chan struct{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.connLost == nil {
+		lost := make(chan struct{})
+		close(lost)
+		return lost
+	}
+	return c.connLost
+}
+
 // IsConnected 检查是否已连接
 func (c *MsmpClient) IsConnected() bool {
 	c.mutex.Lock()
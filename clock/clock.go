@@ -0,0 +1,61 @@
+package clock
+
+import "time"
+
+// Clock 时钟，提供当前时间、定时器和周期定时器，测试时可以替换为可控的实现
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer 与 time.Timer 对应的定时器
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker 与 time.Ticker 对应的周期定时器
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real 使用系统时间的时钟
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time {
+	return r.t.C
+}
+
+func (r realTimer) Stop() bool {
+	return r.t.Stop()
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) C() <-chan time.Time {
+	return r.t.C
+}
+
+func (r realTicker) Stop() {
+	r.t.Stop()
+}
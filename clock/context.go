@@ -0,0 +1,18 @@
+package clock
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext 返回携带时钟的ctx，调度器通过它把自己的时钟传给任务
+func NewContext(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext 返回ctx携带的时钟，没有时返回fallback
+func FromContext(ctx context.Context, fallback Clock) Clock {
+	if c, ok := ctx.Value(contextKey{}).(Clock); ok {
+		return c
+	}
+	return fallback
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/vanilla"
+	"log"
+)
+
+// AllowlistPush 读取原版 whitelist.json 并通过 minecraft:allowlist/set 覆盖服务端白名单
+func AllowlistPush(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	players, err := vanilla.LoadWhitelistFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := cli.SetAllowlist(ctx, players)
+	if err != nil {
+		return err
+	}
+	log.Printf("pushed %d players from %s, server allowlist now has %d players", len(players), path, len(result))
+	return nil
+}
+
+// AllowlistPull 拉取服务端当前白名单并写入原版 whitelist.json
+func AllowlistPull(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	players, err := cli.GetAllowlist(ctx)
+	if err != nil {
+		return err
+	}
+	if err := vanilla.SaveWhitelistFile(path, players); err != nil {
+		return err
+	}
+	log.Printf("pulled %d players into %s", len(players), path)
+	return nil
+}
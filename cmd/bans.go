@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/vanilla"
+	"io/fs"
+	"log"
+	"time"
+)
+
+// BansPush 读取原版 banned-players.json 并通过 minecraft:bans/set 覆盖服务端封禁列表
+func BansPush(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	entries, err := vanilla.LoadBannedPlayersFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := cli.SetBans(ctx, vanilla.UserBans(entries))
+	if err != nil {
+		return err
+	}
+	log.Printf("pushed %d bans from %s, server now has %d bans", len(entries), path, len(result))
+	return nil
+}
+
+// BansPull 拉取服务端封禁列表并写入原版 banned-players.json
+// 文件已存在时，仍在封禁中的玩家保留原来的 created
+func BansPull(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	bans, err := cli.GetBans(ctx)
+	if err != nil {
+		return err
+	}
+	previous, err := vanilla.LoadBannedPlayersFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	entries := vanilla.BannedPlayerEntries(bans, previous, time.Now())
+	if err := vanilla.SaveBannedPlayersFile(path, entries); err != nil {
+		return err
+	}
+	log.Printf("pulled %d bans into %s", len(entries), path)
+	return nil
+}
+
+// IpBansPush 读取原版 banned-ips.json 并通过 minecraft:ip_bans/set 覆盖服务端封禁IP列表
+func IpBansPush(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	entries, err := vanilla.LoadBannedIpsFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := cli.SetIpBans(ctx, vanilla.IpBans(entries))
+	if err != nil {
+		return err
+	}
+	log.Printf("pushed %d ip bans from %s, server now has %d ip bans", len(entries), path, len(result))
+	return nil
+}
+
+// IpBansPull 拉取服务端封禁IP列表并写入原版 banned-ips.json
+// 文件已存在时，仍在封禁中的IP保留原来的 created
+func IpBansPull(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	bans, err := cli.GetIpBans(ctx)
+	if err != nil {
+		return err
+	}
+	previous, err := vanilla.LoadBannedIpsFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	entries := vanilla.BannedIpEntries(bans, previous, time.Now())
+	if err := vanilla.SaveBannedIpsFile(path, entries); err != nil {
+		return err
+	}
+	log.Printf("pulled %d ip bans into %s", len(entries), path)
+	return nil
+}
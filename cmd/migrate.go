@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"io"
+	"sort"
+)
+
+// ServerDiff 比较两个服务端的管理数据，返回使target与source一致所需的变更
+func ServerDiff(ctx context.Context, source, target *mcmsmpgo.MsmpClient, sections []mcmsmpgo.BackupSection) (*mcmsmpgo.RestoreReport, error) {
+	return ServerMigrate(ctx, source, target, sections, true)
+}
+
+// ServerMigrate 将source的指定部分复制到target，sections为空时复制全部
+// dryRun 为true时只比较不修改
+func ServerMigrate(ctx context.Context, source, target *mcmsmpgo.MsmpClient, sections []mcmsmpgo.BackupSection, dryRun bool) (*mcmsmpgo.RestoreReport, error) {
+	doc, err := source.Backup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	report, err := target.Restore(ctx, doc, mcmsmpgo.RestoreOptions{
+		Sections: sections,
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		return report, fmt.Errorf("target: %w", err)
+	}
+	return report, nil
+}
+
+func playerString(p subdto.PlayerDto) string {
+	if p.Id == "" {
+		return p.Name
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, p.Id)
+}
+
+func printSection(w io.Writer, section mcmsmpgo.BackupSection, lines []string) {
+	_, _ = fmt.Fprintf(w, "== %s ==\n", section)
+	if len(lines) == 0 {
+		_, _ = fmt.Fprintln(w, "  (no changes)")
+		return
+	}
+	for _, l := range lines {
+		_, _ = fmt.Fprintln(w, l)
+	}
+}
+
+// PrintReport 按部分输出差异，"+" 表示target缺少，"-" 表示target多出，"~" 表示取值不同
+// "!" 表示target不支持而跳过的设置
+func PrintReport(w io.Writer, report *mcmsmpgo.RestoreReport) {
+	if r := report.Allowlist; r != nil {
+		var lines []string
+		for _, p := range r.Added {
+			lines = append(lines, "+ "+playerString(p))
+		}
+		for _, p := range r.Removed {
+			lines = append(lines, "- "+playerString(p))
+		}
+		printSection(w, mcmsmpgo.SectionAllowlist, lines)
+	}
+	if r := report.Bans; r != nil {
+		var lines []string
+		for _, b := range r.Added {
+			lines = append(lines, fmt.Sprintf("+ %s: %q until %s", playerString(b.Player), b.Reason, b.Expires))
+		}
+		for _, b := range r.Removed {
+			lines = append(lines, "- "+playerString(b.Player))
+		}
+		for _, c := range r.Changed {
+			lines = append(lines, fmt.Sprintf("~ %s: %q until %s -> %q until %s", playerString(c.After.Player),
+				c.Before.Reason, c.Before.Expires, c.After.Reason, c.After.Expires))
+		}
+		printSection(w, mcmsmpgo.SectionBans, lines)
+	}
+	if r := report.IpBans; r != nil {
+		var lines []string
+		for _, b := range r.Added {
+			lines = append(lines, fmt.Sprintf("+ %s: %q until %s", b.Ip, b.Reason, b.Expires))
+		}
+		for _, b := range r.Removed {
+			lines = append(lines, "- "+b.Ip)
+		}
+		for _, c := range r.Changed {
+			lines = append(lines, fmt.Sprintf("~ %s: %q until %s -> %q until %s", c.After.Ip,
+				c.Before.Reason, c.Before.Expires, c.After.Reason, c.After.Expires))
+		}
+		printSection(w, mcmsmpgo.SectionIpBans, lines)
+	}
+	if r := report.Operators; r != nil {
+		var lines []string
+		for _, o := range r.Added {
+			lines = append(lines, fmt.Sprintf("+ %s: level %d", playerString(o.Player), o.PermissionLevel))
+		}
+		for _, o := range r.Removed {
+			lines = append(lines, "- "+playerString(o.Player))
+		}
+		for _, c := range r.Changed {
+			lines = append(lines, fmt.Sprintf("~ %s: level %d -> %d, bypassesPlayerLimit %v -> %v", playerString(c.After.Player),
+				c.Before.PermissionLevel, c.After.PermissionLevel, c.Before.BypassesPlayerLimit, c.After.BypassesPlayerLimit))
+		}
+		printSection(w, mcmsmpgo.SectionOperators, lines)
+	}
+	if report.Gamerules != nil {
+		var lines []string
+		for _, c := range report.Gamerules {
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", c.After.Key, c.Before.Value, c.After.Value))
+		}
+		printSection(w, mcmsmpgo.SectionGamerules, lines)
+	}
+	if report.Settings != nil {
+		names := make([]string, 0, len(report.Settings))
+		for name := range report.Settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var lines []string
+		for _, name := range names {
+			c := report.Settings[name]
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", name, c.Before, c.After))
+		}
+		for _, name := range report.UnsupportedSettings {
+			lines = append(lines, "! "+name+": unsupported by target")
+		}
+		printSection(w, mcmsmpgo.SectionSettings, lines)
+	}
+}
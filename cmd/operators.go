@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/vanilla"
+	"log"
+)
+
+// OperatorChange 管理员的一处变更
+type OperatorChange = mcmsmpgo.Change[subdto.OperatorDto]
+
+// OperatorsSyncReport 同步ops.json时与服务端当前列表的差异
+type OperatorsSyncReport = mcmsmpgo.ReconcileReport[subdto.OperatorDto]
+
+// OperatorsSync 将ops.json应用到服务端，并报告相对于服务端当前列表新增、移除和修改的管理员
+// dryRun 为true时只计算差异，不修改服务端
+func OperatorsSync(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string, dryRun bool) (*OperatorsSyncReport, error) {
+	desired, err := vanilla.LoadOpsFile(path)
+	if err != nil {
+		return nil, err
+	}
+	current, err := cli.GetOperators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report, err := mcmsmpgo.DiffOperators(current, desired)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+	for _, o := range report.Added {
+		log.Printf("+ op %s (level %d, bypassesPlayerLimit %v)", o.Player.Name, o.PermissionLevel, o.BypassesPlayerLimit)
+	}
+	for _, o := range report.Removed {
+		log.Printf("- op %s", o.Player.Name)
+	}
+	for _, c := range report.Changed {
+		log.Printf("~ op %s: level %d -> %d, bypassesPlayerLimit %v -> %v", c.After.Player.Name,
+			c.Before.PermissionLevel, c.After.PermissionLevel, c.Before.BypassesPlayerLimit, c.After.BypassesPlayerLimit)
+	}
+	if dryRun || report.Empty() {
+		return report, nil
+	}
+	if _, err := cli.SetOperators(ctx, desired); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// OperatorsPull 拉取服务端管理员列表并写入原版 ops.json
+func OperatorsPull(ctx context.Context, cli *mcmsmpgo.MsmpClient, path string) error {
+	operators, err := cli.GetOperators(ctx)
+	if err != nil {
+		return err
+	}
+	if err := vanilla.SaveOpsFile(path, operators); err != nil {
+		return err
+	}
+	log.Printf("pulled %d operators into %s", len(operators), path)
+	return nil
+}
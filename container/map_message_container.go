@@ -33,19 +33,17 @@ func (m *MapMessageContainer) NewResponse(r dto.MsmpResponse) (*dto.MessagePair,
 
 func (m *MapMessageContainer) AddRequest(request *dto.MsmpRequest) error {
 	id := request.ID
-	m.mutex.RLock()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	_, exists := m.WaitingMap[id]
-	m.mutex.RUnlock()
 	if exists {
 		return errors.New("duplicate request")
 	}
-	m.mutex.Lock()
 	m.WaitingMap[id] = &dto.MessagePair{
 		Id:       id,
 		Request:  request,
 		Response: nil,
 	}
-	m.mutex.Unlock()
 	return nil
 }
 
@@ -140,6 +138,7 @@ func (m *MapMessageContainer) CancelRequest(id int) error {
 func (m *MapMessageContainer) AddRequestWithHandler(request *dto.MsmpRequest, callback func(*dto.MsmpRequest, dto.MsmpResponse)) error {
 	id := request.ID
 	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	_, exists := m.WaitingMap[id]
 	if exists {
 		return errors.New("duplicate request")
@@ -150,6 +149,5 @@ func (m *MapMessageContainer) AddRequestWithHandler(request *dto.MsmpRequest, ca
 		Response: nil,
 		Callback: callback,
 	}
-	m.mutex.Unlock()
 	return nil
 }
@@ -0,0 +1,130 @@
+package container
+
+import (
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShardNum 默认分片数量
+const DefaultShardNum = 32
+
+// messageShard 单个分片，持有一部分等待中的请求
+type messageShard struct {
+	mutex      sync.Mutex
+	WaitingMap map[int]*dto.MessagePair
+	// 填充到独立缓存行，避免相邻分片的伪共享
+	_ [48]byte
+}
+
+// ShardedMessageContainer 按请求ID分片加锁的消息容器
+// 不同分片之间的请求注册与响应匹配互不阻塞，适合大量goroutine共享同一个客户端的场景
+type ShardedMessageContainer struct {
+	shards     []*messageShard
+	mask       uint
+	waitingNum atomic.Int64
+}
+
+// NewShardedMessageContainer 创建分片消息容器
+// shardNum 会向上取整为2的幂，小于等于0时使用 DefaultShardNum
+func NewShardedMessageContainer(shardNum int) *ShardedMessageContainer {
+	if shardNum <= 0 {
+		shardNum = DefaultShardNum
+	}
+	n := 1
+	for n < shardNum {
+		n <<= 1
+	}
+	shards := make([]*messageShard, n)
+	for i := range shards {
+		shards[i] = &messageShard{
+			WaitingMap: make(map[int]*dto.MessagePair),
+		}
+	}
+	return &ShardedMessageContainer{
+		shards: shards,
+		mask:   uint(n - 1),
+	}
+}
+
+// shard 根据请求ID选择分片，请求ID递增分配，因此低位取模即可均匀分布
+func (m *ShardedMessageContainer) shard(id int) *messageShard {
+	return m.shards[uint(id)&m.mask]
+}
+
+func (m *ShardedMessageContainer) AddRequest(request *dto.MsmpRequest) error {
+	return m.AddRequestWithHandler(request, nil)
+}
+
+func (m *ShardedMessageContainer) AddRequestWithHandler(request *dto.MsmpRequest, callback func(*dto.MsmpRequest, dto.MsmpResponse)) error {
+	id := request.ID
+	s := m.shard(id)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.WaitingMap[id]; exists {
+		return errors.New("duplicate request")
+	}
+	s.WaitingMap[id] = &dto.MessagePair{
+		Id:       id,
+		Request:  request,
+		Response: nil,
+		Callback: callback,
+	}
+	m.waitingNum.Add(1)
+	return nil
+}
+
+func (m *ShardedMessageContainer) NewResponse(r dto.MsmpResponse) (*dto.MessagePair, error) {
+	id := r.GetID()
+	s := m.shard(id)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	v, e := s.WaitingMap[id]
+	if !e {
+		return nil, errors.New("no waiting request")
+	}
+	v.Response = r
+	delete(s.WaitingMap, id)
+	m.waitingNum.Add(-1)
+	return v, nil
+}
+
+func (m *ShardedMessageContainer) GetRequest(id int) (*dto.MsmpRequest, error) {
+	s := m.shard(id)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	v, e := s.WaitingMap[id]
+	if !e {
+		return nil, errors.New("no request")
+	}
+	return v.Request, nil
+}
+
+func (m *ShardedMessageContainer) GetWaitingRequests() ([]*dto.MessagePair, error) {
+	list := make([]*dto.MessagePair, 0, m.waitingNum.Load())
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		for _, v := range s.WaitingMap {
+			list = append(list, v)
+		}
+		s.mutex.Unlock()
+	}
+	return list, nil
+}
+
+func (m *ShardedMessageContainer) GetWaitingNum() (int, error) {
+	return int(m.waitingNum.Load()), nil
+}
+
+func (m *ShardedMessageContainer) CancelRequest(id int) error {
+	s := m.shard(id)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.WaitingMap[id]; !exists {
+		return errors.New("no waiting request")
+	}
+	delete(s.WaitingMap, id)
+	m.waitingNum.Add(-1)
+	return nil
+}
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MsmpNotification 服务端推送的通知，没有ID，不需要响应
+type MsmpNotification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// ParseNotification 解析通知，消息带有ID或没有method时不是通知，返回false
+func ParseNotification(data []byte) (*MsmpNotification, bool) {
+	temp := struct {
+		MsmpNotification
+		ID json.RawMessage `json:"id"`
+	}{}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return nil, false
+	}
+	if temp.Method == "" || (len(temp.ID) > 0 && !bytes.Equal(temp.ID, []byte("null"))) {
+		return nil, false
+	}
+	return &temp.MsmpNotification, true
+}
+
+// DecodeParams 将通知的参数解码到v中
+// 参数为位置参数时解码第一个参数，为命名参数时解码整个对象，没有参数时不修改v
+func (n *MsmpNotification) DecodeParams(v interface{}) error {
+	params := bytes.TrimSpace(n.Params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+	if params[0] != '[' {
+		return json.Unmarshal(params, v)
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(params, &list); err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return json.Unmarshal(list[0], v)
+}
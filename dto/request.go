@@ -1,10 +1,10 @@
 package dto
 
 type MsmpRequest struct {
-	JSONRPC string        `json:"jsonrpc"`
-	ID      int           `json:"id"`
-	Method  string        `json:"method"`
-	Params  []interface{} `json:"params,omitempty"`
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
 }
 
 type MessagePair struct {
@@ -14,9 +14,22 @@ type MessagePair struct {
 	Callback func(request *MsmpRequest, response MsmpResponse)
 }
 
+// Positional 按位置传递的参数列表，每个元素对应一个参数
+type Positional []interface{}
+
+// Named 按名称传递的参数对象
+type Named map[string]interface{}
+
+// NewMsmpRequest 构造请求
+// param 为 Positional 或 Named 时原样作为 params 发送，其余非nil值作为唯一的位置参数
 func NewMsmpRequest(id int, method string, param interface{}) MsmpRequest {
-	if param == nil {
+	switch p := param.(type) {
+	case nil:
 		return MsmpRequest{JSONRPC: "2.0", ID: id, Method: method}
+	case Positional:
+		return MsmpRequest{JSONRPC: "2.0", ID: id, Method: method, Params: []interface{}(p)}
+	case Named:
+		return MsmpRequest{JSONRPC: "2.0", ID: id, Method: method, Params: map[string]interface{}(p)}
 	}
 	return MsmpRequest{JSONRPC: "2.0", ID: id, Method: method, Params: []interface{}{param}}
 }
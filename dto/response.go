@@ -2,6 +2,8 @@ package dto
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 )
 
 // MsmpResponseSuccess 成功响应结构
@@ -18,6 +20,14 @@ type MsmpResponseError struct {
 	Data    string `json:"data,omitempty"`
 }
 
+// Error 实现error接口，使失败响应可以直接作为错误返回
+func (e *MsmpResponseError) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("msmp error %d: %s (%s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("msmp error %d: %s", e.Code, e.Message)
+}
+
 // MsmpResponseFailure 失败响应结构
 type MsmpResponseFailure struct {
 	JSONRPC string            `json:"jsonrpc"`
@@ -120,3 +130,33 @@ func ParseResponse(data []byte) (MsmpResponse, error) {
 		return success, nil
 	}
 }
+
+// RawResult 获取响应结果的原始JSON，失败响应返回对应的 *MsmpResponseError
+func RawResult(resp MsmpResponse) (json.RawMessage, error) {
+	if resp == nil {
+		return nil, errors.New("nil response")
+	}
+	if !resp.IsSuccess() {
+		return nil, resp.GetError()
+	}
+	switch v := (*resp.GetResult().(*interface{})).(type) {
+	case json.RawMessage:
+		return v, nil
+	case nil:
+		return nil, nil
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// DecodeResult 将响应结果解码到v中，失败响应返回对应的 *MsmpResponseError
+func DecodeResult(resp MsmpResponse, v interface{}) error {
+	raw, err := RawResult(resp)
+	if err != nil {
+		return err
+	}
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
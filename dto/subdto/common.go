@@ -6,17 +6,17 @@ type PlayerDto struct {
 }
 
 type UserBanDto struct {
-	Expires string    `json:"expires"`
+	Expires BanExpiry `json:"expires,omitzero"`
 	Player  PlayerDto `json:"player"`
 	Reason  string    `json:"reason"`
 	Source  string    `json:"source"`
 }
 
 type IpBanDTO struct {
-	Expires string `json:"expires"`
-	Ip      string `json:"ip"`
-	Reason  string `json:"reason"`
-	Source  string `json:"source"`
+	Expires BanExpiry `json:"expires,omitzero"`
+	Ip      string    `json:"ip"`
+	Reason  string    `json:"reason"`
+	Source  string    `json:"source"`
 }
 
 type OperatorDto struct {
@@ -40,3 +40,22 @@ type TypedRule struct {
 	Value string `json:"value"`
 	Type  string `json:"type"`
 }
+
+// Message 协议中的消息，literal 与 translatable 二选一
+type Message struct {
+	Literal            string   `json:"literal,omitempty"`
+	Translatable       string   `json:"translatable,omitempty"`
+	TranslatableParams []string `json:"translatableParams,omitempty"`
+}
+
+type SystemMessageDto struct {
+	Message          Message     `json:"message"`
+	Overlay          bool        `json:"overlay"`
+	ReceivingPlayers []PlayerDto `json:"receivingPlayers,omitempty"`
+}
+
+// KickPlayerDto 踢出玩家的参数，message 为空时使用服务端默认的断开消息
+type KickPlayerDto struct {
+	Player  PlayerDto `json:"player"`
+	Message *Message  `json:"message,omitempty"`
+}
@@ -0,0 +1,23 @@
+package subdto
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// NormalizeIP 校验IP地址并转换为标准形式，例如 "0:0:0:0:0:0:0:1" 转换为 "::1"
+func NormalizeIP(ip string) (string, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ip, fmt.Errorf("invalid ip %q: %v", ip, err)
+	}
+	return addr.String(), nil
+}
+
+// Normalize 校验并标准化被封禁的IP
+func (b IpBanDTO) Normalize() (IpBanDTO, error) {
+	ip, err := NormalizeIP(b.Ip)
+	b.Ip = ip
+	return b, err
+}
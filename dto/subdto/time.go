@@ -0,0 +1,78 @@
+package subdto
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// ForeverExpires 部分实现和原版文件中表示永久封禁的 expires 值
+	ForeverExpires = "forever"
+	// VanillaTimeLayout 原版列表文件中的时间格式（yyyy-MM-dd HH:mm:ss Z）
+	VanillaTimeLayout = "2006-01-02 15:04:05 -0700"
+)
+
+// BanExpiry 封禁的到期时间，零值表示永久封禁
+// 序列化为协议使用的 ISO-8601 格式，缺省、null、空字符串和 "forever" 均解析为永久，
+// 同时接受原版文件的时间格式，原版文件的写出由 vanilla 包负责
+type BanExpiry struct {
+	time.Time
+}
+
+// NewBanExpiry 创建在t时刻到期的封禁时间
+func NewBanExpiry(t time.Time) BanExpiry {
+	return BanExpiry{Time: t}
+}
+
+// ExpiresIn 创建从现在起d之后到期的封禁时间，d<=0 表示永久
+func ExpiresIn(d time.Duration) BanExpiry {
+	if d <= 0 {
+		return BanExpiry{}
+	}
+	return BanExpiry{Time: time.Now().Add(d).Truncate(time.Second)}
+}
+
+// Permanent 是否为永久封禁
+func (e BanExpiry) Permanent() bool {
+	return e.IsZero()
+}
+
+// Expired 在now时刻封禁是否已经到期
+func (e BanExpiry) Expired(now time.Time) bool {
+	return !e.Permanent() && !now.Before(e.Time)
+}
+
+func (e BanExpiry) String() string {
+	if e.Permanent() {
+		return ForeverExpires
+	}
+	return e.Format(time.RFC3339)
+}
+
+func (e BanExpiry) MarshalJSON() ([]byte, error) {
+	if e.Permanent() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(e.Format(time.RFC3339))
+}
+
+func (e *BanExpiry) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil || *s == "" || *s == ForeverExpires {
+		*e = BanExpiry{}
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		var fileErr error
+		if t, fileErr = time.Parse(VanillaTimeLayout, *s); fileErr != nil {
+			return fmt.Errorf("invalid ban expiry %q: %v", *s, err)
+		}
+	}
+	*e = BanExpiry{Time: t}
+	return nil
+}
@@ -0,0 +1,148 @@
+package subdto
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID 玩家的唯一标识
+type UUID [16]byte
+
+// ParseUUID 解析带横线（8-4-4-4-12）或不带横线的32位十六进制UUID，不区分大小写
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	raw := s
+	switch len(s) {
+	case 36:
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, fmt.Errorf("invalid uuid %q", s)
+		}
+		raw = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	case 32:
+	default:
+		return u, fmt.Errorf("invalid uuid %q: wrong length", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(raw)); err != nil {
+		return UUID{}, fmt.Errorf("invalid uuid %q: %v", s, err)
+	}
+	return u, nil
+}
+
+// MustParseUUID 解析UUID，失败时panic，用于常量初始化
+func MustParseUUID(s string) UUID {
+	u, err := ParseUUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// OfflineUUID 计算离线模式服务端为玩家分配的UUID
+// 与原版一致，即 "OfflinePlayer:<name>" 的第3版（基于MD5的名称）UUID
+func OfflineUUID(name string) UUID {
+	u := UUID(md5.Sum([]byte("OfflinePlayer:" + name)))
+	u[6] = u[6]&0x0f | 0x30
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+// String 返回小写带横线的形式
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// Undashed 返回小写不带横线的形式
+func (u UUID) Undashed() string {
+	return hex.EncodeToString(u[:])
+}
+
+// IsZero 是否为全零UUID
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// Version 返回UUID版本号，正版玩家为4，离线玩家为3
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(data []byte) error {
+	parsed, err := ParseUUID(string(data))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// NewPlayer 构造玩家结构，Id 使用带横线的标准形式
+func NewPlayer(id UUID, name string) PlayerDto {
+	return PlayerDto{Id: id.String(), Name: name}
+}
+
+// OfflinePlayer 构造离线模式服务端上的玩家结构，无需联网查询
+func OfflinePlayer(name string) PlayerDto {
+	return NewPlayer(OfflineUUID(name), name)
+}
+
+// UUID 解析玩家的Id
+func (p PlayerDto) UUID() (UUID, error) {
+	return ParseUUID(p.Id)
+}
+
+// Normalize 校验Id并转换为小写带横线的标准形式，Id为空时原样返回
+func (p PlayerDto) Normalize() (PlayerDto, error) {
+	if p.Id == "" {
+		return p, nil
+	}
+	u, err := ParseUUID(strings.TrimSpace(p.Id))
+	if err != nil {
+		return p, err
+	}
+	p.Id = u.String()
+	return p, nil
+}
+
+// Normalize 校验并标准化被封禁玩家的Id
+func (b UserBanDto) Normalize() (UserBanDto, error) {
+	p, err := b.Player.Normalize()
+	b.Player = p
+	return b, err
+}
+
+// Normalize 校验并标准化管理员的Id
+func (o OperatorDto) Normalize() (OperatorDto, error) {
+	p, err := o.Player.Normalize()
+	o.Player = p
+	return o, err
+}
+
+// Normalize 校验并标准化被踢出玩家的Id
+func (k KickPlayerDto) Normalize() (KickPlayerDto, error) {
+	p, err := k.Player.Normalize()
+	k.Player = p
+	return k, err
+}
+
+// NormalizeAll 依次标准化items中的每一项，返回新的切片，遇到无效Id时返回错误
+func NormalizeAll[T interface{ Normalize() (T, error) }](items []T) ([]T, error) {
+	if items == nil {
+		return nil, nil
+	}
+	out := make([]T, len(items))
+	for i, v := range items {
+		n, err := v.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError 回调发生panic时上报的错误
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("callback panic: %v", e.Value)
+}
+
+// ErrorHook 回调出错时的上报函数
+type ErrorHook func(pair *dto.MessagePair, err error)
+
+// LogErrorHook 默认的错误上报，写入日志
+func LogErrorHook(pair *dto.MessagePair, err error) {
+	method := ""
+	if pair.Request != nil {
+		method = pair.Request.Method
+	}
+	if p, ok := err.(*PanicError); ok {
+		log.Printf("callback for request %d (%s) panicked: %v\n%s", pair.Id, method, p.Value, p.Stack)
+		return
+	}
+	log.Printf("callback for request %d (%s) failed: %v", pair.Id, method, err)
+}
+
+// Stats 执行器运行指标
+type Stats struct {
+	// 当前排队等待执行的回调数量
+	QueueDepth int64
+	// 历史最大排队数量
+	MaxQueueDepth int64
+	// 正在执行的回调数量
+	Running int64
+	// 已执行完成的回调数量（包括panic的）
+	Completed int64
+	// 发生panic的回调数量
+	Panics int64
+}
+
+// metrics 执行器共用的原子计数
+type metrics struct {
+	queueDepth    atomic.Int64
+	maxQueueDepth atomic.Int64
+	running       atomic.Int64
+	completed     atomic.Int64
+	panics        atomic.Int64
+}
+
+func (m *metrics) enqueue() {
+	depth := m.queueDepth.Add(1)
+	for {
+		maxDepth := m.maxQueueDepth.Load()
+		if depth <= maxDepth || m.maxQueueDepth.CompareAndSwap(maxDepth, depth) {
+			return
+		}
+	}
+}
+
+func (m *metrics) stats() Stats {
+	return Stats{
+		QueueDepth:    m.queueDepth.Load(),
+		MaxQueueDepth: m.maxQueueDepth.Load(),
+		Running:       m.running.Load(),
+		Completed:     m.completed.Load(),
+		Panics:        m.panics.Load(),
+	}
+}
+
+// run 执行回调并从panic中恢复
+func (m *metrics) run(pair *dto.MessagePair, hook ErrorHook) {
+	m.queueDepth.Add(-1)
+	m.running.Add(1)
+	defer func() {
+		m.running.Add(-1)
+		m.completed.Add(1)
+		if r := recover(); r != nil {
+			m.panics.Add(1)
+			if hook != nil {
+				hook(pair, &PanicError{Value: r, Stack: debug.Stack()})
+			}
+		}
+	}()
+	if pair.Callback != nil {
+		pair.Callback(pair.Request, pair.Response)
+	}
+}
@@ -0,0 +1,30 @@
+package executor
+
+import "github.com/CycleZero/mc-msmp-go/dto"
+
+// GoroutineExecutor 每个回调启动一个goroutine执行，不限并发也不保证顺序
+// 这是客户端的默认执行方式，回调panic时通过 ErrorHook 上报而不会导致进程退出
+type GoroutineExecutor struct {
+	metrics
+	errorHook ErrorHook
+}
+
+// NewGoroutineExecutor 创建goroutine执行器，errorHook为nil时使用 LogErrorHook
+func NewGoroutineExecutor(errorHook ErrorHook) *GoroutineExecutor {
+	if errorHook == nil {
+		errorHook = LogErrorHook
+	}
+	return &GoroutineExecutor{errorHook: errorHook}
+}
+
+func (e *GoroutineExecutor) Execute(pair *dto.MessagePair) {
+	e.enqueue()
+	go e.run(pair, e.errorHook)
+}
+
+func (e *GoroutineExecutor) Close() {}
+
+// Stats 获取运行指标
+func (e *GoroutineExecutor) Stats() Stats {
+	return e.stats()
+}
@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"hash/fnv"
+	"sync"
+)
+
+// ErrExecutorClosed Close之后仍有回调提交到工作池
+var ErrExecutorClosed = errors.New("executor is closed")
+
+// Ordering 回调的执行顺序保证
+type Ordering int
+
+const (
+	// Unordered 不保证顺序，所有工作协程共享一个队列
+	Unordered Ordering = iota
+	// GlobalFIFO 所有回调按响应到达顺序依次执行，只使用一个工作协程
+	GlobalFIFO
+	// PerMethodFIFO 同一方法的回调按到达顺序依次执行，不同方法之间可以并发
+	PerMethodFIFO
+)
+
+// PoolConfig 工作池配置
+type PoolConfig struct {
+	// 工作协程数量，默认为4；GlobalFIFO 下固定为1
+	Workers int
+	// 每个队列的容量，默认为1024；队列满时 Execute 阻塞，从而对读协程形成背压
+	QueueSize int
+	// 顺序保证
+	Ordering Ordering
+	// 回调panic或在Close之后提交时的上报函数，默认为 LogErrorHook
+	ErrorHook ErrorHook
+}
+
+// PoolExecutor 有界工作池执行器
+type PoolExecutor struct {
+	metrics
+	queues    []chan *dto.MessagePair
+	ordering  Ordering
+	errorHook ErrorHook
+	wg        sync.WaitGroup
+	// 保护closed，Execute持有读锁发送，Close持有写锁关闭队列，避免向已关闭的队列发送
+	closeMutex sync.RWMutex
+	closed     bool
+}
+
+// NewPoolExecutor 创建工作池执行器并启动工作协程
+func NewPoolExecutor(config PoolConfig) *PoolExecutor {
+	if config.Workers <= 0 {
+		config.Workers = 4
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.ErrorHook == nil {
+		config.ErrorHook = LogErrorHook
+	}
+
+	e := &PoolExecutor{
+		ordering:  config.Ordering,
+		errorHook: config.ErrorHook,
+	}
+	switch config.Ordering {
+	case GlobalFIFO:
+		e.queues = []chan *dto.MessagePair{make(chan *dto.MessagePair, config.QueueSize)}
+		e.start(e.queues[0])
+	case PerMethodFIFO:
+		// 每个工作协程独占一个队列，同一方法总是落在同一个队列上
+		e.queues = make([]chan *dto.MessagePair, config.Workers)
+		for i := range e.queues {
+			e.queues[i] = make(chan *dto.MessagePair, config.QueueSize)
+			e.start(e.queues[i])
+		}
+	default:
+		e.queues = []chan *dto.MessagePair{make(chan *dto.MessagePair, config.QueueSize)}
+		for i := 0; i < config.Workers; i++ {
+			e.start(e.queues[0])
+		}
+	}
+	return e
+}
+
+func (e *PoolExecutor) start(queue chan *dto.MessagePair) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for pair := range queue {
+			e.run(pair, e.errorHook)
+		}
+	}()
+}
+
+// Execute 将回调放入队列，队列满时阻塞
+// Close之后提交的回调不会执行，通过 ErrorHook 上报 ErrExecutorClosed 后丢弃
+func (e *PoolExecutor) Execute(pair *dto.MessagePair) {
+	e.closeMutex.RLock()
+	if e.closed {
+		e.closeMutex.RUnlock()
+		// 上报时不持有锁，ErrorHook 中可以调用 Close
+		e.errorHook(pair, ErrExecutorClosed)
+		return
+	}
+	queue := e.queues[0]
+	if e.ordering == PerMethodFIFO && pair.Request != nil {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(pair.Request.Method))
+		queue = e.queues[h.Sum32()%uint32(len(e.queues))]
+	}
+	e.enqueue()
+	queue <- pair
+	e.closeMutex.RUnlock()
+}
+
+// Close 停止接收新的回调，并等待已排队的回调执行完毕
+func (e *PoolExecutor) Close() {
+	e.closeMutex.Lock()
+	if !e.closed {
+		e.closed = true
+		for _, q := range e.queues {
+			close(q)
+		}
+	}
+	e.closeMutex.Unlock()
+	e.wg.Wait()
+}
+
+// Stats 获取运行指标
+func (e *PoolExecutor) Stats() Stats {
+	return e.stats()
+}
@@ -1,6 +1,9 @@
 package mcmsmpgo
 
-import "github.com/CycleZero/mc-msmp-go/dto/subdto"
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+)
 
 func (c *MsmpClient) Gamerules() {
 	err := c.SendRequest("minecraft:gamerules", nil)
@@ -9,9 +12,23 @@ func (c *MsmpClient) Gamerules() {
 	}
 }
 
-func (c *MsmpClient) GamerulesUpdate(rules []subdto.TypedRule) {
-	err := c.SendRequest("minecraft:gamerules/update", rules)
-	if err != nil {
-		return
+func (c *MsmpClient) GamerulesUpdate(rules []subdto.TypedRule) error {
+	if err := validateGamerules(rules); err != nil {
+		return err
+	}
+	return c.SendRequest("minecraft:gamerules/update", rules)
+}
+
+// GetGamerules 获取游戏规则并等待结果
+func (c *MsmpClient) GetGamerules(ctx context.Context) ([]subdto.TypedRule, error) {
+	return Call[[]subdto.TypedRule](ctx, c, "minecraft:gamerules", nil)
+}
+
+// UpdateGamerules 校验并更新游戏规则，返回服务端更新后的规则
+// 任意一条规则校验失败时不会发送请求
+func (c *MsmpClient) UpdateGamerules(ctx context.Context, rules []subdto.TypedRule) ([]subdto.TypedRule, error) {
+	if err := validateGamerules(rules); err != nil {
+		return nil, err
 	}
+	return Call[[]subdto.TypedRule](ctx, c, "minecraft:gamerules/update", rules)
 }
@@ -0,0 +1,197 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/fileutil"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// GameruleProfile 一组命名的游戏规则取值
+type GameruleProfile struct {
+	Name  string
+	Rules []subdto.TypedRule
+}
+
+// profileRule 解析配置文件中的规则值，支持 true/3 这样的JSON值，也支持 "true"/"3" 这样的字符串
+// 模组规则的字符串值无法确定类型，在应用时使用服务端返回的类型
+func profileRule(key string, raw json.RawMessage) (subdto.TypedRule, error) {
+	rule := subdto.TypedRule{Key: key}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return rule, fmt.Errorf("gamerule %s: %v", key, err)
+	}
+	switch value := v.(type) {
+	case bool:
+		rule.Value = strconv.FormatBool(value)
+		rule.Type = string(GameruleBool)
+	case float64:
+		if value != float64(int(value)) {
+			return rule, fmt.Errorf("gamerule %s: %v is not an integer", key, value)
+		}
+		rule.Value = strconv.Itoa(int(value))
+		rule.Type = string(GameruleInt)
+	case string:
+		rule.Value = value
+	default:
+		return rule, fmt.Errorf("gamerule %s: unsupported value %s", key, raw)
+	}
+	def, ok := LookupGamerule(key)
+	if !ok && rule.Type == "" {
+		return rule, nil
+	}
+	if ok {
+		rule.Type = string(def.Type)
+	}
+	return rule, ValidateGamerule(rule)
+}
+
+// ReadGameruleProfiles 读取配置文件，格式为 {"<配置名>": {"<规则名>": 值, ...}, ...}
+func ReadGameruleProfiles(r io.Reader) (map[string]*GameruleProfile, error) {
+	var doc map[string]map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]*GameruleProfile, len(doc))
+	for name, values := range doc {
+		p := &GameruleProfile{Name: name}
+		for key, raw := range values {
+			rule, err := profileRule(key, raw)
+			if err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+			p.Rules = append(p.Rules, rule)
+		}
+		sort.Slice(p.Rules, func(i, j int) bool {
+			return p.Rules[i].Key < p.Rules[j].Key
+		})
+		profiles[name] = p
+	}
+	return profiles, nil
+}
+
+// WriteGameruleProfiles 按 ReadGameruleProfiles 的格式写出配置
+func WriteGameruleProfiles(w io.Writer, profiles map[string]*GameruleProfile) error {
+	doc := make(map[string]map[string]interface{}, len(profiles))
+	for name, p := range profiles {
+		values := make(map[string]interface{}, len(p.Rules))
+		for _, r := range p.Rules {
+			values[r.Key] = r.Value
+			switch GameruleType(r.Type) {
+			case GameruleBool:
+				if b, err := strconv.ParseBool(r.Value); err == nil {
+					values[r.Key] = b
+				}
+			case GameruleInt:
+				if i, err := strconv.Atoi(r.Value); err == nil {
+					values[r.Key] = i
+				}
+			}
+		}
+		doc[name] = values
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// LoadGameruleProfiles 从文件读取游戏规则配置
+func LoadGameruleProfiles(path string) (map[string]*GameruleProfile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	profiles, err := ReadGameruleProfiles(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return profiles, nil
+}
+
+// SaveGameruleProfiles 将游戏规则配置写入文件
+// 先写入临时文件再重命名，避免写到一半时留下损坏的文件，已有文件的权限保持不变
+func SaveGameruleProfiles(path string, profiles map[string]*GameruleProfile) error {
+	return fileutil.WriteFileAtomic(path, 0o644, func(w io.Writer) error {
+		return WriteGameruleProfiles(w, profiles)
+	})
+}
+
+// GameruleApplyResult 应用配置的结果，记录了被修改规则之前的值
+type GameruleApplyResult struct {
+	Profile string
+	Changes []Change[subdto.TypedRule]
+	DryRun  bool
+}
+
+// RevertProfile 返回恢复到应用前状态的配置，可以保存到文件以便之后回滚
+// 应用前服务端不存在的规则无法回滚，不包含在内
+func (r *GameruleApplyResult) RevertProfile() *GameruleProfile {
+	p := &GameruleProfile{Name: r.Profile + " (revert)"}
+	for _, ch := range r.Changes {
+		if ch.Before.Key == "" {
+			continue
+		}
+		p.Rules = append(p.Rules, ch.Before)
+	}
+	return p
+}
+
+// withServerTypes 为没有声明类型的规则补上服务端返回的类型
+func withServerTypes(current, rules []subdto.TypedRule) []subdto.TypedRule {
+	types := make(map[string]string, len(current))
+	for _, r := range current {
+		types[r.Key] = r.Type
+	}
+	typed := make([]subdto.TypedRule, 0, len(rules))
+	for _, r := range rules {
+		if r.Type == "" {
+			r.Type = types[r.Key]
+		}
+		typed = append(typed, r)
+	}
+	return typed
+}
+
+// ApplyGameruleProfile 获取当前游戏规则，只更新与配置不同的规则
+// 没有类型的模组规则使用服务端返回的类型，服务端也没有该规则时返回错误
+// dryRun 为true时只计算差异
+func (c *MsmpClient) ApplyGameruleProfile(ctx context.Context, profile *GameruleProfile, dryRun bool) (*GameruleApplyResult, error) {
+	current, err := c.GetGamerules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("apply profile %q: %w", profile.Name, err)
+	}
+	rules := withServerTypes(current, profile.Rules)
+	if err := validateGamerules(rules); err != nil {
+		return nil, fmt.Errorf("apply profile %q: %w", profile.Name, err)
+	}
+	result := &GameruleApplyResult{
+		Profile: profile.Name,
+		Changes: DiffGamerules(current, rules),
+		DryRun:  dryRun,
+	}
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+	updates := make([]subdto.TypedRule, 0, len(result.Changes))
+	for _, ch := range result.Changes {
+		updates = append(updates, ch.After)
+	}
+	if _, err := c.UpdateGamerules(ctx, updates); err != nil {
+		return result, fmt.Errorf("apply profile %q: %w", profile.Name, err)
+	}
+	return result, nil
+}
+
+// RevertGameruleProfile 将 ApplyGameruleProfile 修改过的规则恢复为应用前的值
+func (c *MsmpClient) RevertGameruleProfile(ctx context.Context, result *GameruleApplyResult) (*GameruleApplyResult, error) {
+	return c.ApplyGameruleProfile(ctx, result.RevertProfile(), false)
+}
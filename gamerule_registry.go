@@ -0,0 +1,274 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sort"
+	"strconv"
+)
+
+// GameruleType 游戏规则的值类型，对应 TypedRule.Type
+type GameruleType string
+
+const (
+	GameruleBool GameruleType = "boolean"
+	GameruleInt  GameruleType = "integer"
+)
+
+// GameruleDef 原版游戏规则的定义
+type GameruleDef struct {
+	Key     string
+	Type    GameruleType
+	Default string
+}
+
+func boolRule(key string, def bool) GameruleDef {
+	return GameruleDef{Key: key, Type: GameruleBool, Default: strconv.FormatBool(def)}
+}
+
+func intRule(key string, def int) GameruleDef {
+	return GameruleDef{Key: key, Type: GameruleInt, Default: strconv.Itoa(def)}
+}
+
+// gameruleRegistry 原版游戏规则
+var gameruleRegistry = map[string]GameruleDef{}
+
+func init() {
+	for _, def := range []GameruleDef{
+		boolRule("allowEnteringNetherUsingPortals", true),
+		boolRule("allowFireTicksAwayFromPlayer", false),
+		boolRule("announceAdvancements", true),
+		boolRule("blockExplosionDropDecay", true),
+		boolRule("commandBlockOutput", true),
+		intRule("commandModificationBlockLimit", 32768),
+		boolRule("disableElytraMovementCheck", false),
+		boolRule("disablePlayerMovementCheck", false),
+		boolRule("disableRaids", false),
+		boolRule("doDaylightCycle", true),
+		boolRule("doEntityDrops", true),
+		boolRule("doFireTick", true),
+		boolRule("doImmediateRespawn", false),
+		boolRule("doInsomnia", true),
+		boolRule("doLimitedCrafting", false),
+		boolRule("doMobLoot", true),
+		boolRule("doMobSpawning", true),
+		boolRule("doPatrolSpawning", true),
+		boolRule("doTileDrops", true),
+		boolRule("doTraderSpawning", true),
+		boolRule("doVinesSpread", true),
+		boolRule("doWardenSpawning", true),
+		boolRule("doWeatherCycle", true),
+		boolRule("drowningDamage", true),
+		boolRule("enderPearlsVanishOnDeath", true),
+		boolRule("fallDamage", true),
+		boolRule("fireDamage", true),
+		boolRule("forgiveDeadPlayers", true),
+		boolRule("freezeDamage", true),
+		boolRule("globalSoundEvents", true),
+		boolRule("keepInventory", false),
+		boolRule("lavaSourceConversion", false),
+		boolRule("locatorBar", true),
+		boolRule("logAdminCommands", true),
+		intRule("maxCommandChainLength", 65536),
+		intRule("maxCommandForkCount", 65536),
+		intRule("maxEntityCramming", 24),
+		boolRule("mobExplosionDropDecay", true),
+		boolRule("mobGriefing", true),
+		boolRule("naturalRegeneration", true),
+		intRule("playersNetherPortalCreativeDelay", 0),
+		intRule("playersNetherPortalDefaultDelay", 80),
+		intRule("playersSleepingPercentage", 100),
+		boolRule("projectilesCanBreakBlocks", true),
+		boolRule("pvp", true),
+		intRule("randomTickSpeed", 3),
+		boolRule("reducedDebugInfo", false),
+		boolRule("sendCommandFeedback", true),
+		boolRule("showDeathMessages", true),
+		intRule("snowAccumulationHeight", 1),
+		intRule("spawnChunkRadius", 2),
+		intRule("spawnRadius", 10),
+		boolRule("spawnerBlocksEnabled", true),
+		boolRule("spectatorsGenerateChunks", true),
+		boolRule("tntExplodes", true),
+		boolRule("tntExplosionDropDecay", false),
+		boolRule("universalAnger", false),
+		boolRule("waterSourceConversion", true),
+	} {
+		gameruleRegistry[def.Key] = def
+	}
+}
+
+// LookupGamerule 查找原版游戏规则的定义
+func LookupGamerule(key string) (GameruleDef, bool) {
+	def, ok := gameruleRegistry[key]
+	return def, ok
+}
+
+// KnownGamerules 返回所有原版游戏规则，按名称排序
+func KnownGamerules() []GameruleDef {
+	list := make([]GameruleDef, 0, len(gameruleRegistry))
+	for _, def := range gameruleRegistry {
+		list = append(list, def)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// validateValue 按类型严格校验值，布尔值只接受小写的 "true"/"false"
+func validateValue(key string, t GameruleType, value string) error {
+	switch t {
+	case GameruleBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("gamerule %s: %q is not a boolean (want \"true\" or \"false\")", key, value)
+		}
+	case GameruleInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("gamerule %s: %q is not an integer", key, value)
+		}
+	}
+	return nil
+}
+
+// ValidateGamerule 发送前校验规则
+// 原版规则按注册表中的类型校验取值，声明的 Type 必须与注册表一致；未知（模组添加）的规则必须声明 Type，并按该类型校验取值
+func ValidateGamerule(rule subdto.TypedRule) error {
+	def, ok := LookupGamerule(rule.Key)
+	if !ok {
+		if rule.Type == "" {
+			return fmt.Errorf("unknown gamerule %s: type must be set for non-vanilla rules", rule.Key)
+		}
+		return validateValue(rule.Key, GameruleType(rule.Type), rule.Value)
+	}
+	if rule.Type != "" && GameruleType(rule.Type) != def.Type {
+		return fmt.Errorf("gamerule %s: type %q does not match registered type %q", rule.Key, rule.Type, def.Type)
+	}
+	return validateValue(rule.Key, def.Type, rule.Value)
+}
+
+// validateGamerules 依次校验每条规则，返回第一个错误
+func validateGamerules(rules []subdto.TypedRule) error {
+	for _, r := range rules {
+		if err := ValidateGamerule(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GameruleMap 以名称为键的游戏规则，包含服务端返回的未知规则
+type GameruleMap map[string]subdto.TypedRule
+
+// NewGameruleMap 由规则列表构造
+func NewGameruleMap(rules []subdto.TypedRule) GameruleMap {
+	m := make(GameruleMap, len(rules))
+	for _, r := range rules {
+		m[r.Key] = r
+	}
+	return m
+}
+
+// Bool 读取布尔规则
+func (m GameruleMap) Bool(key string) (bool, error) {
+	r, ok := m[key]
+	if !ok {
+		return false, fmt.Errorf("gamerule %s not found", key)
+	}
+	if err := validateValue(key, GameruleBool, r.Value); err != nil {
+		return false, err
+	}
+	return r.Value == "true", nil
+}
+
+// Int 读取整数规则
+func (m GameruleMap) Int(key string) (int, error) {
+	r, ok := m[key]
+	if !ok {
+		return 0, fmt.Errorf("gamerule %s not found", key)
+	}
+	v, err := strconv.Atoi(r.Value)
+	if err != nil {
+		return 0, fmt.Errorf("gamerule %s: %q is not an integer", key, r.Value)
+	}
+	return v, nil
+}
+
+// SetBool 设置布尔规则
+func (m GameruleMap) SetBool(key string, value bool) {
+	m[key] = subdto.TypedRule{Key: key, Value: strconv.FormatBool(value), Type: string(GameruleBool)}
+}
+
+// SetInt 设置整数规则
+func (m GameruleMap) SetInt(key string, value int) {
+	m[key] = subdto.TypedRule{Key: key, Value: strconv.Itoa(value), Type: string(GameruleInt)}
+}
+
+// Rules 返回按名称排序的规则列表
+func (m GameruleMap) Rules() []subdto.TypedRule {
+	list := make([]subdto.TypedRule, 0, len(m))
+	for _, r := range m {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// GetGameruleMap 获取游戏规则并解码为 GameruleMap
+func (c *MsmpClient) GetGameruleMap(ctx context.Context) (GameruleMap, error) {
+	rules, err := c.GetGamerules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewGameruleMap(rules), nil
+}
+
+// SetGameruleBool 校验并设置布尔规则
+func (c *MsmpClient) SetGameruleBool(ctx context.Context, key string, value bool) error {
+	_, err := c.UpdateGamerules(ctx, []subdto.TypedRule{{Key: key, Value: strconv.FormatBool(value), Type: string(GameruleBool)}})
+	return err
+}
+
+// SetGameruleInt 校验并设置整数规则
+func (c *MsmpClient) SetGameruleInt(ctx context.Context, key string, value int) error {
+	_, err := c.UpdateGamerules(ctx, []subdto.TypedRule{{Key: key, Value: strconv.Itoa(value), Type: string(GameruleInt)}})
+	return err
+}
+
+// SetKeepInventory 设置死亡后是否保留物品栏
+func (c *MsmpClient) SetKeepInventory(ctx context.Context, value bool) error {
+	return c.SetGameruleBool(ctx, "keepInventory", value)
+}
+
+// SetDoDaylightCycle 设置是否进行昼夜更替
+func (c *MsmpClient) SetDoDaylightCycle(ctx context.Context, value bool) error {
+	return c.SetGameruleBool(ctx, "doDaylightCycle", value)
+}
+
+// SetDoWeatherCycle 设置天气是否变化
+func (c *MsmpClient) SetDoWeatherCycle(ctx context.Context, value bool) error {
+	return c.SetGameruleBool(ctx, "doWeatherCycle", value)
+}
+
+// SetMobGriefing 设置生物是否能破坏方块
+func (c *MsmpClient) SetMobGriefing(ctx context.Context, value bool) error {
+	return c.SetGameruleBool(ctx, "mobGriefing", value)
+}
+
+// SetPvp 设置玩家之间是否可以互相伤害
+func (c *MsmpClient) SetPvp(ctx context.Context, value bool) error {
+	return c.SetGameruleBool(ctx, "pvp", value)
+}
+
+// SetRandomTickSpeed 设置随机刻速度
+func (c *MsmpClient) SetRandomTickSpeed(ctx context.Context, value int) error {
+	return c.SetGameruleInt(ctx, "randomTickSpeed", value)
+}
+
+// SetPlayersSleepingPercentage 设置跳过夜晚所需的睡觉玩家百分比
+func (c *MsmpClient) SetPlayersSleepingPercentage(ctx context.Context, value int) error {
+	return c.SetGameruleInt(ctx, "playersSleepingPercentage", value)
+}
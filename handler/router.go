@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HandlerFunc 响应处理函数，与 NewClientConfig.Handler 签名一致
+type HandlerFunc func(request *dto.MsmpRequest, response dto.MsmpResponse)
+
+type prefixRoute struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// Router 按请求方法分发响应
+// 路由规则可以是完整方法名，例如 "minecraft:players"；
+// 也可以以 "*" 结尾表示前缀，例如 "minecraft:*" 匹配整个命名空间，
+// "minecraft:allowlist/*" 同时匹配 "minecraft:allowlist" 和 "minecraft:allowlist/add"。
+// 完整方法名优先，其次是最长的前缀，都未匹配时交给兜底处理函数。
+type Router struct {
+	mutex    sync.RWMutex
+	exact    map[string]HandlerFunc
+	prefixes []prefixRoute
+	fallback HandlerFunc
+}
+
+// NewRouter 创建路由，默认兜底处理函数为 DefaultHandler
+func NewRouter() *Router {
+	return &Router{
+		exact:    make(map[string]HandlerFunc),
+		fallback: DefaultHandler,
+	}
+}
+
+// Handle 注册路由，重复注册同一规则时覆盖之前的处理函数
+func (r *Router) Handle(pattern string, handler HandlerFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !strings.HasSuffix(pattern, "*") {
+		r.exact[pattern] = handler
+		return
+	}
+	prefix := strings.TrimSuffix(pattern, "*")
+	for i := range r.prefixes {
+		if r.prefixes[i].prefix == prefix {
+			r.prefixes[i].handler = handler
+			return
+		}
+	}
+	r.prefixes = append(r.prefixes, prefixRoute{prefix: prefix, handler: handler})
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].prefix) > len(r.prefixes[j].prefix)
+	})
+}
+
+// Fallback 设置未匹配任何路由时的处理函数，nil表示丢弃
+func (r *Router) Fallback(handler HandlerFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fallback = handler
+}
+
+// Match 返回方法对应的处理函数
+func (r *Router) Match(method string) HandlerFunc {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if h, ok := r.exact[method]; ok {
+		return h
+	}
+	for _, p := range r.prefixes {
+		if strings.HasPrefix(method, p.prefix) || method+"/" == p.prefix {
+			return p.handler
+		}
+	}
+	return r.fallback
+}
+
+// Dispatch 分发响应，可直接作为 NewClientConfig.Handler 使用
+func (r *Router) Dispatch(request *dto.MsmpRequest, response dto.MsmpResponse) {
+	if request == nil {
+		return
+	}
+	if h := r.Match(request.Method); h != nil {
+		h(request, response)
+	}
+}
+
+// Decode 将响应结果解码为T，失败响应返回 *dto.MsmpResponseError
+func Decode[T any](response dto.MsmpResponse) (T, error) {
+	var result T
+	if err := dto.DecodeResult(response, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// HandleTyped 注册带类型解码的路由
+// 服务端返回错误或解码失败时，handler 收到T的零值和对应的错误
+func HandleTyped[T any](r *Router, pattern string, handler func(request *dto.MsmpRequest, result T, err error)) {
+	r.Handle(pattern, func(request *dto.MsmpRequest, response dto.MsmpResponse) {
+		result, err := Decode[T](response)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", request.Method, err)
+		}
+		handler(request, result, err)
+	})
+}
@@ -0,0 +1,10 @@
+package iface
+
+import "github.com/CycleZero/mc-msmp-go/dto"
+
+// CallbackExecutor 负责执行已匹配到响应的请求回调
+// Execute 由读协程按响应到达顺序调用
+type CallbackExecutor interface {
+	Execute(pair *dto.MessagePair)
+	Close()
+}
@@ -1,12 +1,17 @@
 package mcmsmpgo
 
-import "github.com/CycleZero/mc-msmp-go/dto/subdto"
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"time"
+)
 
-func (c *MsmpClient) IpBansSet(bans []subdto.IpBanDTO) {
-	err := c.SendRequest("minecraft:ip_bans/set", bans)
+func (c *MsmpClient) IpBansSet(bans []subdto.IpBanDTO) error {
+	bans, err := subdto.NormalizeAll(bans)
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:ip_bans/set", bans)
 }
 
 func (c *MsmpClient) IpBans() {
@@ -16,21 +21,23 @@ func (c *MsmpClient) IpBans() {
 	}
 }
 
-func (c *MsmpClient) IpBansAdd(ban subdto.IpBanDTO) {
-	err := c.SendRequest("minecraft:ip_bans/add", ban)
+func (c *MsmpClient) IpBansAdd(ban subdto.IpBanDTO) error {
+	ban, err := ban.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:ip_bans/add", ban)
 }
 
-func (c *MsmpClient) IpBansRemove(ip string) {
+func (c *MsmpClient) IpBansRemove(ip string) error {
+	ip, err := subdto.NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
 	param := map[string]string{
 		"ip": ip,
 	}
-	err := c.SendRequest("minecraft:ip_bans/remove", param)
-	if err != nil {
-		return
-	}
+	return c.SendRequest("minecraft:ip_bans/remove", param)
 }
 
 func (c *MsmpClient) IpBansClear() {
@@ -39,3 +46,35 @@ func (c *MsmpClient) IpBansClear() {
 		return
 	}
 }
+
+// GetIpBans 获取封禁IP列表并等待结果
+func (c *MsmpClient) GetIpBans(ctx context.Context) ([]subdto.IpBanDTO, error) {
+	return Call[[]subdto.IpBanDTO](ctx, c, "minecraft:ip_bans", nil)
+}
+
+// SetIpBans 用bans替换整个封禁IP列表，返回服务端设置后的列表
+// IP会被标准化，存在无效IP时不发送请求并返回错误
+func (c *MsmpClient) SetIpBans(ctx context.Context, bans []subdto.IpBanDTO) ([]subdto.IpBanDTO, error) {
+	bans, err := subdto.NormalizeAll(bans)
+	if err != nil {
+		return nil, err
+	}
+	if bans == nil {
+		bans = []subdto.IpBanDTO{}
+	}
+	return Call[[]subdto.IpBanDTO](ctx, c, "minecraft:ip_bans/set", bans)
+}
+
+// IpBanFor 封禁IP duration时长，duration<=0 表示永久封禁，返回服务端添加后的封禁IP列表
+func (c *MsmpClient) IpBanFor(ctx context.Context, ip string, duration time.Duration, reason string) ([]subdto.IpBanDTO, error) {
+	ip, err := subdto.NormalizeIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	ban := subdto.IpBanDTO{
+		Expires: subdto.ExpiresIn(duration),
+		Ip:      ip,
+		Reason:  reason,
+	}
+	return Call[[]subdto.IpBanDTO](ctx, c, "minecraft:ip_bans/add", ban)
+}
@@ -0,0 +1,64 @@
+package mcmsmpgo
+
+import "sync"
+
+// eventListeners 事件监听列表，事件按发生顺序逐个投递给全部监听函数
+// 调用方在持有自己的锁时调用 push 入队，释放锁后调用 deliver 投递，保证事件顺序与状态变化顺序一致，
+// 监听函数运行时不持有任何锁，可以调用调用方的查询方法
+type eventListeners[E any] struct {
+	mutex     sync.Mutex
+	listeners map[int]func(E)
+	listenID  int
+	pending   []E
+
+	// 保证事件按发生顺序逐个投递
+	deliverMutex sync.Mutex
+}
+
+// add 注册监听函数，返回取消监听的函数
+func (l *eventListeners[E]) add(fn func(E)) (remove func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.listeners == nil {
+		l.listeners = make(map[int]func(E))
+	}
+	l.listenID++
+	id := l.listenID
+	l.listeners[id] = fn
+	return func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		delete(l.listeners, id)
+	}
+}
+
+// push 将事件加入待投递队列
+func (l *eventListeners[E]) push(events ...E) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.pending = append(l.pending, events...)
+}
+
+// deliver 按顺序投递待处理的事件
+func (l *eventListeners[E]) deliver() {
+	l.deliverMutex.Lock()
+	defer l.deliverMutex.Unlock()
+	for {
+		l.mutex.Lock()
+		events := l.pending
+		l.pending = nil
+		listeners := make([]func(E), 0, len(l.listeners))
+		for _, fn := range l.listeners {
+			listeners = append(listeners, fn)
+		}
+		l.mutex.Unlock()
+		if len(events) == 0 {
+			return
+		}
+		for _, e := range events {
+			for _, fn := range listeners {
+				fn(e)
+			}
+		}
+	}
+}
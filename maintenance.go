@@ -0,0 +1,247 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"time"
+)
+
+// ErrNotInMaintenance 客户端没有记录进入维护模式前的状态
+var ErrNotInMaintenance = errors.New("server is not in maintenance mode")
+
+// ErrAlreadyInMaintenance 已经通过该客户端进入维护模式
+var ErrAlreadyInMaintenance = errors.New("server is already in maintenance mode")
+
+// maintenanceRollbackTimeout 进入维护模式失败后恢复设置的超时时间
+// 恢复不使用调用方的ctx，调用方取消或超时后仍会尝试恢复
+const maintenanceRollbackTimeout = 5 * time.Second
+
+// MaintenanceOptions 进入维护模式的选项
+type MaintenanceOptions struct {
+	// 维护期间的motd，为空时不修改
+	Motd string
+	// 踢出玩家前广播的警告，为零值时不广播
+	Warning subdto.Message
+	// 广播警告后等待多久再踢出玩家，按ctx携带的时钟或客户端的时钟（NewClientConfig.Clock）计时
+	Grace time.Duration
+	// 踢出玩家时显示的消息，为零值时使用服务端默认的断开消息
+	KickMessage subdto.Message
+	// 不会被踢出的玩家，按UUID匹配，没有UUID时按名称匹配
+	// 不在白名单中的豁免玩家会在开启强制白名单前被加入白名单，退出维护模式时移除
+	Exempt []subdto.PlayerDto
+}
+
+// MaintenanceState 进入维护模式前的服务端设置，可以序列化保存，之后用 RestoreMaintenance 恢复
+type MaintenanceState struct {
+	// 进入维护模式前的设置原始值，只包含被修改过的设置
+	Settings map[string]json.RawMessage `json:"settings"`
+	// 为豁免玩家新添加的白名单，退出时只移除这些玩家
+	Allowlisted []subdto.PlayerDto `json:"allowlisted"`
+	// 进入维护模式时被踢出的玩家
+	Kicked []subdto.PlayerDto `json:"kicked"`
+}
+
+// EnterMaintenance 进入维护模式：
+// 记录当前设置，广播警告，将豁免玩家加入白名单，开启并强制白名单，替换motd，踢出不在豁免列表中的在线玩家。
+// 修改设置时出错会尝试恢复已修改的设置并移除新加入白名单的玩家，即使ctx已经取消或超时。
+// 等待宽限期时不持有锁，此期间再次进入会返回 ErrAlreadyInMaintenance。
+func (c *MsmpClient) EnterMaintenance(ctx context.Context, opts MaintenanceOptions) (*MaintenanceState, error) {
+	c.maintenanceMutex.Lock()
+	if c.maintenance != nil || c.maintenancePending {
+		c.maintenanceMutex.Unlock()
+		return nil, ErrAlreadyInMaintenance
+	}
+	c.maintenancePending = true
+	c.maintenanceMutex.Unlock()
+	defer func() {
+		c.maintenanceMutex.Lock()
+		c.maintenancePending = false
+		c.maintenanceMutex.Unlock()
+	}()
+
+	desired := map[string]interface{}{
+		SettingUseAllowlist:     true,
+		SettingEnforceAllowlist: true,
+	}
+	if opts.Motd != "" {
+		desired[SettingMotd] = opts.Motd
+	}
+	state := &MaintenanceState{Settings: make(map[string]json.RawMessage, len(desired))}
+	for name := range desired {
+		value, err := c.GetServerSetting(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("enter maintenance: %w", err)
+		}
+		state.Settings[name] = value
+	}
+
+	if opts.Warning.Literal != "" || opts.Warning.Translatable != "" {
+		if err := c.SendSystemMessage(ctx, subdto.SystemMessageDto{Message: opts.Warning}); err != nil {
+			return nil, fmt.Errorf("enter maintenance: %w", err)
+		}
+		if opts.Grace > 0 {
+			timer := c.clockFor(ctx).NewTimer(opts.Grace)
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("enter maintenance: %w", ctx.Err())
+			}
+		}
+	}
+
+	// 开启强制白名单前先把豁免玩家加入白名单，否则服务端会将他们踢出
+	if err := c.allowlistExempt(ctx, opts.Exempt, state); err != nil {
+		if restoreErr := c.rollbackMaintenance(ctx, state); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+		return nil, fmt.Errorf("enter maintenance: %w", err)
+	}
+
+	for _, name := range []string{SettingUseAllowlist, SettingEnforceAllowlist, SettingMotd} {
+		value, ok := desired[name]
+		if !ok {
+			continue
+		}
+		if _, err := c.SetServerSetting(ctx, name, value); err != nil {
+			if restoreErr := c.rollbackMaintenance(ctx, state); restoreErr != nil {
+				err = errors.Join(err, restoreErr)
+			}
+			return nil, fmt.Errorf("enter maintenance: %w", err)
+		}
+	}
+
+	exempt := make(map[string]bool, len(opts.Exempt))
+	for _, p := range opts.Exempt {
+		exempt[PlayerKey(p)] = true
+	}
+	kicked, err := c.KickWhere(ctx, func(p subdto.PlayerDto) bool {
+		return !exempt[PlayerKey(p)] && !exempt[PlayerKey(subdto.PlayerDto{Name: p.Name})]
+	}, opts.KickMessage)
+	state.Kicked = kicked
+	// 设置已经修改，即使踢出失败也记录状态，以便之后退出维护模式
+	c.maintenanceMutex.Lock()
+	c.maintenance = state
+	c.maintenanceMutex.Unlock()
+	if err != nil {
+		return state, fmt.Errorf("enter maintenance: %w", err)
+	}
+	return state, nil
+}
+
+// allowlistExempt 将不在白名单中的豁免玩家加入白名单，并记录到state
+// 只有名称的豁免玩家如果在线，使用在线玩家的UUID
+func (c *MsmpClient) allowlistExempt(ctx context.Context, exempt []subdto.PlayerDto, state *MaintenanceState) error {
+	if len(exempt) == 0 {
+		return nil
+	}
+	allowlist, err := c.GetAllowlist(ctx)
+	if err != nil {
+		return err
+	}
+	online, err := c.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool, len(allowlist))
+	for _, p := range allowlist {
+		listed[PlayerKey(p)] = true
+		listed[PlayerKey(subdto.PlayerDto{Name: p.Name})] = true
+	}
+	byName := make(map[string]subdto.PlayerDto, len(online))
+	for _, p := range online {
+		byName[PlayerKey(subdto.PlayerDto{Name: p.Name})] = p
+	}
+	var added []subdto.PlayerDto
+	for _, p := range exempt {
+		if p.Id == "" {
+			if resolved, ok := byName[PlayerKey(p)]; ok {
+				p = resolved
+			}
+		}
+		if listed[PlayerKey(p)] || listed[PlayerKey(subdto.PlayerDto{Name: p.Name})] {
+			continue
+		}
+		player, err := p.Normalize()
+		if err != nil {
+			return err
+		}
+		listed[PlayerKey(player)] = true
+		added = append(added, player)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	if _, err := CallRaw(ctx, c, "minecraft:allowlist/add", added); err != nil {
+		return err
+	}
+	state.Allowlisted = added
+	return nil
+}
+
+// ExitMaintenance 退出通过该客户端进入的维护模式，将设置恢复为进入前的值
+func (c *MsmpClient) ExitMaintenance(ctx context.Context) error {
+	c.maintenanceMutex.Lock()
+	defer c.maintenanceMutex.Unlock()
+	if c.maintenance == nil {
+		return ErrNotInMaintenance
+	}
+	if err := c.restoreMaintenance(ctx, c.maintenance); err != nil {
+		return fmt.Errorf("exit maintenance: %w", err)
+	}
+	c.maintenance = nil
+	return nil
+}
+
+// RestoreMaintenance 按保存的状态退出维护模式，用于进程重启后恢复
+// 只有state就是该客户端记录的维护模式状态时才清除记录
+func (c *MsmpClient) RestoreMaintenance(ctx context.Context, state *MaintenanceState) error {
+	c.maintenanceMutex.Lock()
+	defer c.maintenanceMutex.Unlock()
+	if err := c.restoreMaintenance(ctx, state); err != nil {
+		return fmt.Errorf("exit maintenance: %w", err)
+	}
+	if state == c.maintenance {
+		c.maintenance = nil
+	}
+	return nil
+}
+
+// Maintenance 返回该客户端记录的维护模式状态，不在维护模式时返回nil
+func (c *MsmpClient) Maintenance() *MaintenanceState {
+	c.maintenanceMutex.Lock()
+	defer c.maintenanceMutex.Unlock()
+	return c.maintenance
+}
+
+// rollbackMaintenance 进入维护模式失败时恢复设置，使用独立的有超时的ctx
+func (c *MsmpClient) rollbackMaintenance(ctx context.Context, state *MaintenanceState) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), maintenanceRollbackTimeout)
+	defer cancel()
+	return c.restoreMaintenance(ctx, state)
+}
+
+// restoreMaintenance 按 motd、enforce_allowlist、use_allowlist 的顺序恢复设置，
+// 先关闭强制白名单再关闭白名单，避免恢复过程中误踢玩家；
+// 设置恢复后再从白名单中移除维护模式添加的玩家，维护期间以其他方式加入白名单的玩家不受影响
+func (c *MsmpClient) restoreMaintenance(ctx context.Context, state *MaintenanceState) error {
+	var errs []error
+	for _, name := range []string{SettingMotd, SettingEnforceAllowlist, SettingUseAllowlist} {
+		value, ok := state.Settings[name]
+		if !ok {
+			continue
+		}
+		if _, err := c.SetServerSetting(ctx, name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(state.Allowlisted) > 0 {
+		if _, err := CallRaw(ctx, c, "minecraft:allowlist/remove", state.Allowlisted); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
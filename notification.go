@@ -0,0 +1,73 @@
+package mcmsmpgo
+
+import (
+	"github.com/CycleZero/mc-msmp-go/dto"
+)
+
+// 服务端推送的通知
+const (
+	NotificationServerStarted  = "minecraft:notification/server/started"
+	NotificationServerStopping = "minecraft:notification/server/stopping"
+	NotificationServerSaving   = "minecraft:notification/server/saving"
+	NotificationServerSaved    = "minecraft:notification/server/saved"
+	NotificationServerStatus   = "minecraft:notification/server/status"
+	NotificationPlayersJoined  = "minecraft:notification/players/joined"
+	NotificationPlayersLeft    = "minecraft:notification/players/left"
+)
+
+// Subscribe 订阅指定方法的通知，返回取消订阅的函数
+// 处理函数由读协程直接调用，不能阻塞，需要发起请求时应在新的goroutine中进行
+func (c *MsmpClient) Subscribe(method string, fn func(*dto.MsmpNotification)) (unsubscribe func()) {
+	c.subscribeMutex.Lock()
+	defer c.subscribeMutex.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]map[int]func(*dto.MsmpNotification))
+	}
+	if c.subscriptions[method] == nil {
+		c.subscriptions[method] = make(map[int]func(*dto.MsmpNotification))
+	}
+	c.subscriptionID++
+	id := c.subscriptionID
+	c.subscriptions[method][id] = fn
+	return func() {
+		c.subscribeMutex.Lock()
+		defer c.subscribeMutex.Unlock()
+		delete(c.subscriptions[method], id)
+		if len(c.subscriptions[method]) == 0 {
+			delete(c.subscriptions, method)
+		}
+	}
+}
+
+// dispatchNotification 调用订阅了该通知的处理函数
+func (c *MsmpClient) dispatchNotification(n *dto.MsmpNotification) {
+	c.subscribeMutex.Lock()
+	handlers := make([]func(*dto.MsmpNotification), 0, len(c.subscriptions[n.Method]))
+	for _, fn := range c.subscriptions[n.Method] {
+		handlers = append(handlers, fn)
+	}
+	c.subscribeMutex.Unlock()
+	for _, fn := range handlers {
+		fn(n)
+	}
+}
+
+// notifications 订阅多个通知并转发到channel，channel满时丢弃新的通知
+// 需要在发送请求前订阅，避免错过请求之后立即到达的通知
+func (c *MsmpClient) notifications(size int, methods ...string) (<-chan *dto.MsmpNotification, func()) {
+	ch := make(chan *dto.MsmpNotification, size)
+	unsubscribes := make([]func(), 0, len(methods))
+	for _, method := range methods {
+		unsubscribes = append(unsubscribes, c.Subscribe(method, func(n *dto.MsmpNotification) {
+			select {
+			case ch <- n:
+			default:
+			}
+		}))
+	}
+	return ch, func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package mcmsmpgo
 
-import "github.com/CycleZero/mc-msmp-go/dto/subdto"
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+)
 
-func (c *MsmpClient) OperatorsSet(operators []subdto.OperatorDto) {
-	err := c.SendRequest("minecraft:operators/set", operators)
+func (c *MsmpClient) OperatorsSet(operators []subdto.OperatorDto) error {
+	operators, err := subdto.NormalizeAll(operators)
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:operators/set", operators)
 }
 
 func (c *MsmpClient) Operators() {
@@ -16,18 +20,20 @@ func (c *MsmpClient) Operators() {
 	}
 }
 
-func (c *MsmpClient) OperatorsAdd(operator subdto.OperatorDto) {
-	err := c.SendRequest("minecraft:operators/add", operator)
+func (c *MsmpClient) OperatorsAdd(operator subdto.OperatorDto) error {
+	operator, err := operator.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:operators/add", operator)
 }
 
-func (c *MsmpClient) OperatorsRemove(player subdto.PlayerDto) {
-	err := c.SendRequest("minecraft:operators/remove", player)
+func (c *MsmpClient) OperatorsRemove(player subdto.PlayerDto) error {
+	player, err := player.Normalize()
 	if err != nil {
-		return
+		return err
 	}
+	return c.SendRequest("minecraft:operators/remove", player)
 }
 
 func (c *MsmpClient) OperatorsClear() {
@@ -36,3 +42,21 @@ func (c *MsmpClient) OperatorsClear() {
 		return
 	}
 }
+
+// GetOperators 获取管理员列表并等待结果
+func (c *MsmpClient) GetOperators(ctx context.Context) ([]subdto.OperatorDto, error) {
+	return Call[[]subdto.OperatorDto](ctx, c, "minecraft:operators", nil)
+}
+
+// SetOperators 用operators替换整个管理员列表，返回服务端设置后的列表
+// 玩家Id会被标准化，存在无效Id时不发送请求并返回错误
+func (c *MsmpClient) SetOperators(ctx context.Context, operators []subdto.OperatorDto) ([]subdto.OperatorDto, error) {
+	operators, err := subdto.NormalizeAll(operators)
+	if err != nil {
+		return nil, err
+	}
+	if operators == nil {
+		operators = []subdto.OperatorDto{}
+	}
+	return Call[[]subdto.OperatorDto](ctx, c, "minecraft:operators/set", operators)
+}
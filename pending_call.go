@@ -0,0 +1,118 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"sync"
+)
+
+// ErrCallCanceled 请求在收到响应前被取消
+var ErrCallCanceled = errors.New("call canceled")
+
+// ErrConnectionLost 收到响应或服务端确认之前连接断开
+var ErrConnectionLost = errors.New("connection lost")
+
+// PendingCall 一次已发送、尚在等待响应的异步调用
+type PendingCall struct {
+	// 请求ID
+	ID int
+
+	// 调用的方法
+	Method string
+
+	// 发送的请求
+	Request *dto.MsmpRequest
+
+	// 收到的响应，Done关闭后有效
+	Response dto.MsmpResponse
+
+	// 调用失败的原因，Done关闭后有效；服务端错误为 *dto.MsmpResponseError
+	Error error
+
+	// 调用完成时关闭
+	Done chan struct{}
+
+	client *MsmpClient
+	once   sync.Once
+}
+
+// Go 异步发送请求并立即返回 PendingCall，响应不再交由 Handler 处理
+func (c *MsmpClient) Go(method string, params interface{}) (*PendingCall, error) {
+	p := &PendingCall{
+		Method: method,
+		Done:   make(chan struct{}),
+		client: c,
+	}
+	request, err := c.send(method, params, func(_ *dto.MsmpRequest, response dto.MsmpResponse) {
+		if response == nil {
+			p.finish(nil, ErrConnectionLost)
+			return
+		}
+		p.finish(response, nil)
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = request.ID
+	p.Request = request
+	return p, nil
+}
+
+// finish 记录调用结果，只有第一次生效
+func (p *PendingCall) finish(response dto.MsmpResponse, err error) {
+	p.once.Do(func() {
+		p.Response = response
+		if err == nil && response != nil && !response.IsSuccess() {
+			err = response.GetError()
+		}
+		p.Error = err
+		close(p.Done)
+	})
+}
+
+// Wait 等待调用完成，ctx结束时返回ctx的错误但不取消请求
+func (p *PendingCall) Wait(ctx context.Context) (dto.MsmpResponse, error) {
+	select {
+	case <-p.Done:
+		return p.Response, p.Error
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Decode 等待调用完成并将结果解码到v中
+func (p *PendingCall) Decode(ctx context.Context, v interface{}) error {
+	response, err := p.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.Method, err)
+	}
+	if err := dto.DecodeResult(response, v); err != nil {
+		return fmt.Errorf("%s: failed to decode result: %w", p.Method, err)
+	}
+	return nil
+}
+
+// Cancel 放弃等待响应，之后到达的响应将被丢弃
+func (p *PendingCall) Cancel() {
+	_ = p.client.container.CancelRequest(p.ID)
+	p.client.inlineRequests.Delete(p.ID)
+	p.finish(nil, ErrCallCanceled)
+}
+
+// WaitAll 等待所有调用完成，返回第一个失败调用的错误
+func WaitAll(ctx context.Context, calls ...*PendingCall) error {
+	var firstErr error
+	for _, p := range calls {
+		if _, err := p.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", p.Method, err)
+			}
+		}
+	}
+	return firstErr
+}
@@ -1,6 +1,9 @@
 package mcmsmpgo
 
-import "github.com/CycleZero/mc-msmp-go/dto/subdto"
+import (
+	"context"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+)
 
 func (c *MsmpClient) Players() {
 	err := c.SendRequest("minecraft:players", nil)
@@ -9,13 +12,62 @@ func (c *MsmpClient) Players() {
 	}
 }
 
-func (c *MsmpClient) PlayersKick(player subdto.PlayerDto, reason string) {
+func (c *MsmpClient) PlayersKick(player subdto.PlayerDto, reason string) error {
+	player, err := player.Normalize()
+	if err != nil {
+		return err
+	}
 	param := map[string]interface{}{
 		"player": player,
 		"reason": reason,
 	}
-	err := c.SendRequest("minecraft:players/kick", param)
+	return c.SendRequest("minecraft:players/kick", param)
+}
+
+// GetPlayers 获取在线玩家列表并等待结果
+func (c *MsmpClient) GetPlayers(ctx context.Context) ([]subdto.PlayerDto, error) {
+	return Call[[]subdto.PlayerDto](ctx, c, "minecraft:players", nil)
+}
+
+// KickPlayers 在一次请求中踢出多个玩家，所有玩家看到相同的消息，返回被踢出的玩家
+// message 可以由 text.Component.Message() 构造，为零值时使用服务端默认的断开消息
+func (c *MsmpClient) KickPlayers(ctx context.Context, message subdto.Message, players ...subdto.PlayerDto) ([]subdto.PlayerDto, error) {
+	kicks := make([]subdto.KickPlayerDto, 0, len(players))
+	for _, p := range players {
+		kick := subdto.KickPlayerDto{Player: p}
+		if message.Literal != "" || message.Translatable != "" {
+			m := message
+			kick.Message = &m
+		}
+		kicks = append(kicks, kick)
+	}
+	return c.Kick(ctx, kicks)
+}
+
+// Kick 在一次请求中踢出多个玩家，每个玩家可以有不同的消息，返回被踢出的玩家
+// 玩家Id会被标准化，存在无效Id时不发送请求并返回错误
+func (c *MsmpClient) Kick(ctx context.Context, kicks []subdto.KickPlayerDto) ([]subdto.PlayerDto, error) {
+	if len(kicks) == 0 {
+		return []subdto.PlayerDto{}, nil
+	}
+	kicks, err := subdto.NormalizeAll(kicks)
 	if err != nil {
-		return
+		return nil, err
+	}
+	return Call[[]subdto.PlayerDto](ctx, c, "minecraft:players/kick", kicks)
+}
+
+// KickWhere 获取在线玩家，踢出所有满足match的玩家，返回被踢出的玩家
+func (c *MsmpClient) KickWhere(ctx context.Context, match func(subdto.PlayerDto) bool, message subdto.Message) ([]subdto.PlayerDto, error) {
+	online, err := c.GetPlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var targets []subdto.PlayerDto
+	for _, p := range online {
+		if match(p) {
+			targets = append(targets, p)
+		}
 	}
+	return c.KickPlayers(ctx, message, targets...)
 }
@@ -0,0 +1,176 @@
+package playtime
+
+import (
+	"encoding/csv"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Period 一个时间段内的在线时长
+type Period struct {
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Summary 玩家的游玩时长汇总
+type Summary struct {
+	Player subdto.PlayerDto
+	Total  time.Duration
+	// 记录数，压缩后同一天的会话计为一次
+	Sessions  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Total 返回玩家的总在线时长
+func (s *Store) Total(player subdto.PlayerDto) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var total time.Duration
+	for _, r := range s.records[key(player)] {
+		total += r.Duration()
+	}
+	return total
+}
+
+// FirstSeen 返回玩家第一次上线的时间，没有记录时返回false
+func (s *Store) FirstSeen(player subdto.PlayerDto) (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := s.records[key(player)]
+	if len(list) == 0 {
+		return time.Time{}, false
+	}
+	return list[0].Start, true
+}
+
+// LastSeen 返回玩家最后一次下线的时间，没有记录时返回false
+func (s *Store) LastSeen(player subdto.PlayerDto) (time.Time, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := s.records[key(player)]
+	if len(list) == 0 {
+		return time.Time{}, false
+	}
+	last := list[0].End
+	for _, r := range list[1:] {
+		if r.End.After(last) {
+			last = r.End
+		}
+	}
+	return last, true
+}
+
+// Daily 返回 [from, to) 内每天的在线时长，日期按from所在的时区划分，没有在线的日期时长为0
+func (s *Store) Daily(player subdto.PlayerDto, from, to time.Time) []Period {
+	return s.breakdown(player, startOfDay(from), to, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+}
+
+// Weekly 返回 [from, to) 内每周（周一开始）的在线时长，按from所在的时区划分
+func (s *Store) Weekly(player subdto.PlayerDto, from, to time.Time) []Period {
+	day := startOfDay(from)
+	offset := (int(day.Weekday()) + 6) % 7
+	return s.breakdown(player, day.AddDate(0, 0, -offset), to, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 7)
+	})
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// breakdown 将记录的在线时长按与每个时间段重叠的比例分配
+func (s *Store) breakdown(player subdto.PlayerDto, start, to time.Time, next func(time.Time) time.Time) []Period {
+	var periods []Period
+	for t := start; t.Before(to); t = next(t) {
+		periods = append(periods, Period{Start: t})
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, r := range s.records[key(player)] {
+		span := r.End.Sub(r.Start)
+		for i := range periods {
+			periodEnd := next(periods[i].Start)
+			if !r.Start.Before(periodEnd) || !r.End.After(periods[i].Start) {
+				continue
+			}
+			if span <= 0 {
+				periods[i].Duration += r.Duration()
+				continue
+			}
+			overlap := minTime(r.End, periodEnd).Sub(maxTime(r.Start, periods[i].Start))
+			periods[i].Duration += time.Duration(float64(r.Duration()) * float64(overlap) / float64(span))
+		}
+	}
+	return periods
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Summaries 返回所有玩家的汇总，按总时长从多到少排序
+func (s *Store) Summaries() []Summary {
+	s.mutex.Lock()
+	list := make([]Summary, 0, len(s.records))
+	for uuid, records := range s.records {
+		sum := Summary{Player: subdto.PlayerDto{Id: uuid, Name: s.names[uuid]}}
+		for _, r := range records {
+			sum.Total += r.Duration()
+			sum.Sessions++
+			if sum.FirstSeen.IsZero() || r.Start.Before(sum.FirstSeen) {
+				sum.FirstSeen = r.Start
+			}
+			if r.End.After(sum.LastSeen) {
+				sum.LastSeen = r.End
+			}
+		}
+		list = append(list, sum)
+	}
+	s.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Total != list[j].Total {
+			return list[i].Total > list[j].Total
+		}
+		return list[i].Player.Name < list[j].Player.Name
+	})
+	return list
+}
+
+// WriteCSV 导出所有玩家的汇总，时长以秒为单位，时间为RFC3339格式
+// 压缩过的记录按天计为一次会话
+func (s *Store) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"uuid", "name", "total_seconds", "sessions", "first_seen", "last_seen"}); err != nil {
+		return err
+	}
+	for _, sum := range s.Summaries() {
+		if err := cw.Write([]string{
+			sum.Player.Id,
+			sum.Player.Name,
+			strconv.FormatInt(int64(sum.Total/time.Second), 10),
+			strconv.Itoa(sum.Sessions),
+			sum.FirstSeen.UTC().Format(time.RFC3339),
+			sum.LastSeen.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
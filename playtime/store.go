@@ -0,0 +1,332 @@
+package playtime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/fileutil"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Record 存储中的一条记录
+// 原始记录对应一次会话，Seconds等于End-Start；压缩后的记录汇总了同一玩家在同一天（UTC）的多次会话，
+// Start与End为第一次上线和最后一次下线的时间，Seconds为实际在线的秒数
+type Record struct {
+	UUID    string    `json:"uuid"`
+	Name    string    `json:"name"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Seconds int64     `json:"seconds"`
+}
+
+// Duration 记录的在线时长
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.Seconds) * time.Second
+}
+
+// Store 追加写入的JSONL游玩时长存储，所有查询在内存中进行
+type Store struct {
+	path string
+
+	mutex   sync.Mutex
+	file    *os.File
+	records map[string][]Record
+	names   map[string]string
+}
+
+// Open 打开或创建存储文件并加载全部记录，无法解析的行会被跳过
+// 文件末尾没有换行符的行（例如崩溃时写了一半的行）无法解析时被截断，能够解析时补上换行符，保证之后追加的记录从新行开始
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		records: make(map[string][]Record),
+		names:   make(map[string]string),
+	}
+	partial, complete, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if partial >= 0 && !complete {
+		if err := os.Truncate(path, partial); err != nil {
+			return nil, err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if partial >= 0 && complete {
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	s.file = f
+	return s, nil
+}
+
+// load 加载全部记录，返回末尾缺少换行符的行的起始位置（没有时为-1）以及这一行是否为有效的记录
+func (s *Store) load() (partial int64, complete bool, err error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return -1, false, nil
+	}
+	if err != nil {
+		return -1, false, err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	partial = -1
+	var offset int64
+	line := 0
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return -1, false, readErr
+		}
+		if len(data) > 0 {
+			line++
+			terminated := data[len(data)-1] == '\n'
+			valid := s.parse(bytes.TrimSpace(data), line)
+			if !terminated {
+				partial, complete = offset, valid
+			}
+			offset += int64(len(data))
+		}
+		if readErr != nil {
+			return partial, complete, nil
+		}
+	}
+}
+
+// parse 解析并索引一行记录，空行和无法解析的行返回false
+func (s *Store) parse(data []byte, line int) bool {
+	if len(data) == 0 {
+		return false
+	}
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil || r.UUID == "" {
+		log.Printf("playtime: skipping invalid record at %s:%d", s.path, line)
+		return false
+	}
+	s.index(r)
+	return true
+}
+
+// index 将记录加入内存索引，调用方需持有锁
+func (s *Store) index(r Record) {
+	list := s.records[r.UUID]
+	// 文件按写入顺序追加，通常已经有序，只在乱序时插入到正确的位置
+	i := len(list)
+	for i > 0 && list[i-1].Start.After(r.Start) {
+		i--
+	}
+	list = append(list, Record{})
+	copy(list[i+1:], list[i:])
+	list[i] = r
+	s.records[r.UUID] = list
+	if r.Name != "" && (i == len(list)-1 || s.names[r.UUID] == "") {
+		s.names[r.UUID] = r.Name
+	}
+}
+
+// key 存储使用规范化的UUID作为玩家的键，没有有效UUID时使用离线模式的UUID
+func key(player subdto.PlayerDto) string {
+	if u, err := player.UUID(); err == nil && !u.IsZero() {
+		return u.String()
+	}
+	return subdto.OfflineUUID(player.Name).String()
+}
+
+// Add 追加一次已结束的会话
+func (s *Store) Add(session mcmsmpgo.Session) error {
+	if session.Online() {
+		return errors.New("session has not ended")
+	}
+	r := Record{
+		UUID:    key(session.Player),
+		Name:    session.Player.Name,
+		Start:   session.Start.UTC(),
+		End:     session.End.UTC(),
+		Seconds: int64(session.End.Sub(session.Start) / time.Second),
+	}
+	if r.Seconds < 0 {
+		return errors.New("session ends before it starts")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return errors.New("store is closed")
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.index(r)
+	return nil
+}
+
+// Attach 监听在线列表，记录每次结束的会话
+// 返回的函数取消监听，并把仍在线的会话按在线列表的时钟结束记录，用于进程退出前保存。
+// detach 是最终操作：在线列表中这些会话仍然保持在线，之后不应再对同一个在线列表调用 Attach，
+// 否则这些会话结束时会被再次记录。重复调用 detach 不会重复记录。
+func (s *Store) Attach(roster *mcmsmpgo.Roster) (detach func() error) {
+	remove := roster.AddListener(func(e mcmsmpgo.RosterEvent) {
+		if e.Type != mcmsmpgo.SessionEnded {
+			return
+		}
+		if err := s.Add(e.Session); err != nil {
+			log.Printf("playtime: failed to record session of %s: %v", e.Session.Player.Name, err)
+		}
+	})
+	var once sync.Once
+	var err error
+	return func() error {
+		once.Do(func() {
+			remove()
+			now := roster.Clock().Now()
+			var errs []error
+			for _, session := range roster.Online() {
+				session.End = now
+				errs = append(errs, s.Add(session))
+			}
+			err = errors.Join(errs...)
+		})
+		return err
+	}
+}
+
+// Close 关闭存储文件
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Compact 将before之前的记录按玩家和UTC日期合并为一条，并重写文件，同时去掉无法解析的行
+// 合并后的记录仍能给出准确的总时长和UTC日期的每日时长，其他时区的每日时长按比例估算
+func (s *Store) Compact(before time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return errors.New("store is closed")
+	}
+
+	compacted := make(map[string][]Record, len(s.records))
+	for uuid, list := range s.records {
+		days := make(map[time.Time]*Record)
+		var kept []Record
+		for _, r := range list {
+			if !r.End.Before(before) {
+				kept = append(kept, r)
+				continue
+			}
+			for _, part := range splitUTCDays(r) {
+				day := part.Start.Truncate(24 * time.Hour)
+				agg, ok := days[day]
+				if !ok {
+					p := part
+					days[day] = &p
+					continue
+				}
+				if part.Start.Before(agg.Start) {
+					agg.Start = part.Start
+				}
+				if part.End.After(agg.End) {
+					agg.End = part.End
+					agg.Name = part.Name
+				}
+				agg.Seconds += part.Seconds
+			}
+		}
+		merged := make([]Record, 0, len(days)+len(kept))
+		for _, r := range days {
+			merged = append(merged, *r)
+		}
+		merged = append(merged, kept...)
+		sort.Slice(merged, func(i, j int) bool {
+			return merged[i].Start.Before(merged[j].Start)
+		})
+		compacted[uuid] = merged
+	}
+
+	uuids := make([]string, 0, len(compacted))
+	for uuid := range compacted {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	// 重命名前关闭原文件，部分平台无法替换仍被打开的文件
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	writeErr := fileutil.WriteFileAtomic(s.path, 0o644, func(out io.Writer) error {
+		w := bufio.NewWriter(out)
+		for _, uuid := range uuids {
+			for _, r := range compacted[uuid] {
+				data, err := json.Marshal(r)
+				if err != nil {
+					return err
+				}
+				if _, err := w.Write(append(data, '\n')); err != nil {
+					return err
+				}
+			}
+		}
+		return w.Flush()
+	})
+	// 写入失败时重新打开原文件，存储保持可用
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	if writeErr != nil {
+		return fmt.Errorf("compact %s: %w", s.path, writeErr)
+	}
+	s.records = compacted
+	return nil
+}
+
+// splitUTCDays 将跨越UTC零点的记录拆分为每天一段，秒数按时间比例分配
+func splitUTCDays(r Record) []Record {
+	var parts []Record
+	total := r.End.Sub(r.Start)
+	remaining := r.Seconds
+	for start := r.Start; start.Before(r.End); {
+		end := start.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		if !end.Before(r.End) {
+			end = r.End
+		}
+		part := Record{UUID: r.UUID, Name: r.Name, Start: start, End: end}
+		if end.Equal(r.End) {
+			part.Seconds = remaining
+		} else {
+			part.Seconds = int64(float64(r.Seconds) * float64(end.Sub(start)) / float64(total))
+		}
+		remaining -= part.Seconds
+		parts = append(parts, part)
+		start = end
+	}
+	if len(parts) == 0 {
+		parts = append(parts, r)
+	}
+	return parts
+}
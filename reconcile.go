@@ -0,0 +1,240 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"strings"
+)
+
+// ErrDuplicateKey 期望列表中存在key相同的多条记录
+var ErrDuplicateKey = errors.New("duplicate key in desired list")
+
+// ReconcileOptions 收敛选项
+type ReconcileOptions struct {
+	// 只计算差异，不修改服务端
+	DryRun bool
+}
+
+// Change 一条记录修改前后的值
+type Change[T any] struct {
+	Before T
+	After  T
+}
+
+// ReconcileReport 当前列表与期望列表之间的差异
+type ReconcileReport[T any] struct {
+	Added   []T
+	Removed []T
+	Changed []Change[T]
+	// 是否为演练，演练时差异未被应用
+	DryRun bool
+}
+
+// Empty 是否没有任何差异
+func (r *ReconcileReport[T]) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// Diff 按key比较当前列表与期望列表，equal判断同一条记录是否需要修改
+// desired中存在key相同的记录时返回 ErrDuplicateKey，避免哪一条生效取决于顺序
+func Diff[T any](current, desired []T, key func(T) string, equal func(current, desired T) bool) (*ReconcileReport[T], error) {
+	report := &ReconcileReport[T]{}
+	live := make(map[string]T, len(current))
+	for _, v := range current {
+		live[key(v)] = v
+	}
+	seen := make(map[string]bool, len(desired))
+	for _, v := range desired {
+		k := key(v)
+		if seen[k] {
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKey, k)
+		}
+		seen[k] = true
+		before, ok := live[k]
+		if !ok {
+			report.Added = append(report.Added, v)
+			continue
+		}
+		if !equal(before, v) {
+			report.Changed = append(report.Changed, Change[T]{Before: before, After: v})
+		}
+	}
+	for _, v := range current {
+		if !seen[key(v)] {
+			report.Removed = append(report.Removed, v)
+		}
+	}
+	return report, nil
+}
+
+// PlayerKey 识别玩家的键，优先使用标准化后的UUID，没有UUID时使用小写名称
+func PlayerKey(p subdto.PlayerDto) string {
+	if p.Id != "" {
+		if u, err := subdto.ParseUUID(strings.TrimSpace(p.Id)); err == nil {
+			return u.String()
+		}
+		return p.Id
+	}
+	return "name:" + strings.ToLower(p.Name)
+}
+
+// sameOrUnset 期望值为空表示不关心，交给服务端默认值
+func sameOrUnset(current, desired string) bool {
+	return desired == "" || current == desired
+}
+
+// DiffAllowlist 比较白名单，玩家只有增删没有修改
+func DiffAllowlist(current, desired []subdto.PlayerDto) (*ReconcileReport[subdto.PlayerDto], error) {
+	return Diff(current, desired, PlayerKey, func(subdto.PlayerDto, subdto.PlayerDto) bool {
+		return true
+	})
+}
+
+// DiffBans 比较封禁玩家列表，原因、来源或到期时间不同视为修改
+// 期望中为空的原因和来源不参与比较
+func DiffBans(current, desired []subdto.UserBanDto) (*ReconcileReport[subdto.UserBanDto], error) {
+	return Diff(current, desired, func(b subdto.UserBanDto) string {
+		return PlayerKey(b.Player)
+	}, func(c, d subdto.UserBanDto) bool {
+		return sameOrUnset(c.Reason, d.Reason) && sameOrUnset(c.Source, d.Source) && c.Expires.Equal(d.Expires.Time)
+	})
+}
+
+// IpKey 识别封禁IP的键，使用标准形式的IP，例如 "0:0:0:0:0:0:0:1" 与 "::1" 视为同一个IP
+func IpKey(ip string) string {
+	if normalized, err := subdto.NormalizeIP(ip); err == nil {
+		return normalized
+	}
+	return ip
+}
+
+// DiffIpBans 比较封禁IP列表，原因、来源或到期时间不同视为修改
+// 期望中为空的原因和来源不参与比较
+func DiffIpBans(current, desired []subdto.IpBanDTO) (*ReconcileReport[subdto.IpBanDTO], error) {
+	return Diff(current, desired, func(b subdto.IpBanDTO) string {
+		return IpKey(b.Ip)
+	}, func(c, d subdto.IpBanDTO) bool {
+		return sameOrUnset(c.Reason, d.Reason) && sameOrUnset(c.Source, d.Source) && c.Expires.Equal(d.Expires.Time)
+	})
+}
+
+// DiffOperators 比较管理员列表，权限等级或是否无视人数上限不同视为修改
+func DiffOperators(current, desired []subdto.OperatorDto) (*ReconcileReport[subdto.OperatorDto], error) {
+	return Diff(current, desired, func(o subdto.OperatorDto) string {
+		return PlayerKey(o.Player)
+	}, func(c, d subdto.OperatorDto) bool {
+		return c.PermissionLevel == d.PermissionLevel && c.BypassesPlayerLimit == d.BypassesPlayerLimit
+	})
+}
+
+// apply 最多调用一次 remove 和一次 add：先批量移除多余和需要修改的记录，再批量添加新增和修改后的记录
+// 修改通过先移除再添加完成，不依赖 add 覆盖已有记录
+func apply[T any](ctx context.Context, c *MsmpClient, report *ReconcileReport[T], addMethod, removeMethod string, removeParam func(T) interface{}, opts ReconcileOptions) (*ReconcileReport[T], error) {
+	report.DryRun = opts.DryRun
+	if opts.DryRun {
+		return report, nil
+	}
+	removes := make([]interface{}, 0, len(report.Removed)+len(report.Changed))
+	adds := make([]T, 0, len(report.Added)+len(report.Changed))
+	for _, v := range report.Removed {
+		removes = append(removes, removeParam(v))
+	}
+	adds = append(adds, report.Added...)
+	for _, ch := range report.Changed {
+		removes = append(removes, removeParam(ch.Before))
+		adds = append(adds, ch.After)
+	}
+	if len(removes) > 0 {
+		if _, err := CallRaw(ctx, c, removeMethod, removes); err != nil {
+			return report, err
+		}
+	}
+	if len(adds) > 0 {
+		if _, err := CallRaw(ctx, c, addMethod, adds); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// ReconcileAllowlist 使服务端白名单收敛到desired，只对有差异的玩家调用 add/remove
+func (c *MsmpClient) ReconcileAllowlist(ctx context.Context, desired []subdto.PlayerDto, opts ReconcileOptions) (*ReconcileReport[subdto.PlayerDto], error) {
+	desired, err := subdto.NormalizeAll(desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile allowlist: %w", err)
+	}
+	current, err := c.GetAllowlist(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile allowlist: %w", err)
+	}
+	report, err := DiffAllowlist(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile allowlist: %w", err)
+	}
+	return apply(ctx, c, report, "minecraft:allowlist/add", "minecraft:allowlist/remove",
+		func(p subdto.PlayerDto) interface{} {
+			return p
+		}, opts)
+}
+
+// ReconcileBans 使服务端封禁玩家列表收敛到desired，修改的封禁先移除再重新添加
+func (c *MsmpClient) ReconcileBans(ctx context.Context, desired []subdto.UserBanDto, opts ReconcileOptions) (*ReconcileReport[subdto.UserBanDto], error) {
+	desired, err := subdto.NormalizeAll(desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile bans: %w", err)
+	}
+	current, err := c.GetBans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile bans: %w", err)
+	}
+	report, err := DiffBans(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile bans: %w", err)
+	}
+	return apply(ctx, c, report, "minecraft:bans/add", "minecraft:bans/remove",
+		func(b subdto.UserBanDto) interface{} {
+			return b.Player
+		}, opts)
+}
+
+// ReconcileIpBans 使服务端封禁IP列表收敛到desired，修改的封禁先移除再重新添加
+func (c *MsmpClient) ReconcileIpBans(ctx context.Context, desired []subdto.IpBanDTO, opts ReconcileOptions) (*ReconcileReport[subdto.IpBanDTO], error) {
+	desired, err := subdto.NormalizeAll(desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile ip bans: %w", err)
+	}
+	current, err := c.GetIpBans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile ip bans: %w", err)
+	}
+	report, err := DiffIpBans(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile ip bans: %w", err)
+	}
+	return apply(ctx, c, report, "minecraft:ip_bans/add", "minecraft:ip_bans/remove",
+		func(b subdto.IpBanDTO) interface{} {
+			return map[string]string{"ip": b.Ip}
+		}, opts)
+}
+
+// ReconcileOperators 使服务端管理员列表收敛到desired，修改的管理员先移除再重新添加
+func (c *MsmpClient) ReconcileOperators(ctx context.Context, desired []subdto.OperatorDto, opts ReconcileOptions) (*ReconcileReport[subdto.OperatorDto], error) {
+	desired, err := subdto.NormalizeAll(desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile operators: %w", err)
+	}
+	current, err := c.GetOperators(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile operators: %w", err)
+	}
+	report, err := DiffOperators(current, desired)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile operators: %w", err)
+	}
+	return apply(ctx, c, report, "minecraft:operators/add", "minecraft:operators/remove",
+		func(o subdto.OperatorDto) interface{} {
+			return o.Player
+		}, opts)
+}
@@ -0,0 +1,373 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session 玩家的一次在线记录，End为零值表示仍在线
+type Session struct {
+	Player subdto.PlayerDto `json:"player"`
+	Start  time.Time        `json:"start"`
+	End    time.Time        `json:"end,omitzero"`
+}
+
+// Online 会话是否仍在进行
+func (s Session) Online() bool {
+	return s.End.IsZero()
+}
+
+// Duration 会话时长，进行中的会话计算到now
+func (s Session) Duration(now time.Time) time.Duration {
+	if s.Online() {
+		return now.Sub(s.Start)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// RosterEventType 在线列表事件类型
+type RosterEventType int
+
+const (
+	// SessionStarted 玩家上线
+	SessionStarted RosterEventType = iota
+	// SessionEnded 玩家下线，Session.End已设置
+	SessionEnded
+)
+
+// RosterEvent 在线列表变化事件
+type RosterEvent struct {
+	Type    RosterEventType
+	Session Session
+	// 事件是否由定期对账发现，而不是来自通知；此时会话的开始或结束时间是对账时间
+	Reconciled bool
+	// 会话是否因连接断开而结束；此时会话的结束时间是发现连接断开的时间
+	Disconnected bool
+}
+
+// RosterConfig 在线列表配置
+type RosterConfig struct {
+	// 定期与 minecraft:players 对账的间隔，默认为1分钟
+	ReconcileInterval time.Duration
+	// 每个玩家保留的历史会话数量，默认为100
+	HistoryLimit int
+	// 时钟，默认为客户端的时钟
+	Clock clock.Clock
+}
+
+// Roster 根据玩家上下线通知维护在线玩家列表和会话记录，并定期与服务端对账修正丢失的通知
+// 连接断开时以断开的时间结束所有在线会话，重新连接后由对账重新开始
+type Roster struct {
+	client   *MsmpClient
+	interval time.Duration
+	limit    int
+	clock    clock.Clock
+
+	mutex     sync.Mutex
+	online    map[string]*Session
+	history   map[string][]Session
+	peak      int
+	peakAt    time.Time
+	listeners eventListeners[RosterEvent]
+	// 每收到一条上下线通知加一，notified 记录每个玩家最后一条通知的序号，
+	// 对账时跳过在请求发出后收到过通知的玩家，避免旧的快照覆盖更新的通知
+	seq      uint64
+	notified map[string]uint64
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewRoster 创建在线列表，config为nil时使用默认配置，调用 Start 后开始跟踪
+func NewRoster(client *MsmpClient, config *RosterConfig) *Roster {
+	r := &Roster{
+		client:   client,
+		interval: time.Minute,
+		limit:    100,
+		clock:    client.Clock(),
+		online:   make(map[string]*Session),
+		history:  make(map[string][]Session),
+		notified: make(map[string]uint64),
+	}
+	if config != nil {
+		if config.ReconcileInterval > 0 {
+			r.interval = config.ReconcileInterval
+		}
+		if config.HistoryLimit > 0 {
+			r.limit = config.HistoryLimit
+		}
+		if config.Clock != nil {
+			r.clock = config.Clock
+		}
+	}
+	return r
+}
+
+// Start 订阅上下线通知并立即对账一次，之后定期对账，ctx结束或调用 Stop 时停止
+// 连接断开时结束所有在线会话，重新连接后的下一次对账重新记录在线玩家
+// 首次对账失败时返回错误，但仍会继续跟踪，之后的对账会修正在线列表
+func (r *Roster) Start(ctx context.Context) error {
+	r.mutex.Lock()
+	if r.cancel != nil {
+		r.mutex.Unlock()
+		return errors.New("roster already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.stopped = make(chan struct{})
+	r.mutex.Unlock()
+
+	unsubscribeJoined := r.client.Subscribe(NotificationPlayersJoined, func(n *dto.MsmpNotification) {
+		var player subdto.PlayerDto
+		if err := n.DecodeParams(&player); err != nil {
+			log.Printf("roster: invalid %s params: %v", n.Method, err)
+			return
+		}
+		r.joined(player, nil)
+	})
+	unsubscribeLeft := r.client.Subscribe(NotificationPlayersLeft, func(n *dto.MsmpNotification) {
+		var player subdto.PlayerDto
+		if err := n.DecodeParams(&player); err != nil {
+			log.Printf("roster: invalid %s params: %v", n.Method, err)
+			return
+		}
+		r.left(player, nil)
+	})
+
+	lost := r.client.ConnectionLost()
+	err := r.Reconcile(ctx)
+	go func() {
+		defer close(r.stopped)
+		defer unsubscribeJoined()
+		defer unsubscribeLeft()
+		ticker := r.clock.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lost:
+				r.connectionLost()
+				// 连接断开后 ConnectionLost 一直处于关闭状态，等到下一次对账时再重新获取
+				lost = nil
+			case <-ticker.C():
+				if lost == nil {
+					lost = r.client.ConnectionLost()
+				}
+				if err := r.Reconcile(ctx); err != nil && ctx.Err() == nil {
+					log.Printf("roster: reconcile failed: %v", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return err
+}
+
+// Stop 停止跟踪，已记录的会话保留
+func (r *Roster) Stop() {
+	r.mutex.Lock()
+	cancel, stopped := r.cancel, r.stopped
+	r.cancel = nil
+	r.mutex.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-stopped
+}
+
+// Reconcile 获取在线玩家并修正在线列表：补上没有收到上线通知的玩家，结束没有收到下线通知的会话
+// 请求发出后收到过上下线通知的玩家以通知为准，不按返回的快照修改
+func (r *Roster) Reconcile(ctx context.Context) error {
+	r.mutex.Lock()
+	since := r.seq
+	r.mutex.Unlock()
+	players, err := r.client.GetPlayers(ctx)
+	if err != nil {
+		return err
+	}
+	live := make(map[string]subdto.PlayerDto, len(players))
+	for _, p := range players {
+		live[PlayerKey(p)] = p
+	}
+
+	r.mutex.Lock()
+	var gone []subdto.PlayerDto
+	for key, s := range r.online {
+		if _, ok := live[key]; !ok {
+			gone = append(gone, s.Player)
+		}
+	}
+	r.mutex.Unlock()
+
+	for _, p := range players {
+		r.joined(p, &since)
+	}
+	for _, p := range gone {
+		r.left(p, &since)
+	}
+	return nil
+}
+
+// Clock 返回在线列表使用的时钟
+func (r *Roster) Clock() clock.Clock {
+	return r.clock
+}
+
+// AddListener 注册事件监听，返回取消监听的函数
+// 事件按发生顺序逐个投递，监听函数可以调用 Roster 的查询方法，但不能阻塞
+func (r *Roster) AddListener(fn func(RosterEvent)) (remove func()) {
+	return r.listeners.add(fn)
+}
+
+// joined 记录玩家上线，since 不为nil时表示由对账发现，值为对账请求发出时的通知序号
+func (r *Roster) joined(player subdto.PlayerDto, since *uint64) {
+	key := PlayerKey(player)
+	reconciled := since != nil
+	r.mutex.Lock()
+	if since == nil {
+		r.seq++
+		r.notified[key] = r.seq
+	} else if r.notified[key] > *since {
+		// 对账请求发出后收到过该玩家的通知，快照已经过时
+		r.mutex.Unlock()
+		return
+	}
+	if _, ok := r.online[key]; ok {
+		r.mutex.Unlock()
+		return
+	}
+	now := r.clock.Now()
+	s := &Session{Player: player, Start: now}
+	r.online[key] = s
+	if len(r.online) > r.peak {
+		r.peak = len(r.online)
+		r.peakAt = now
+	}
+	r.listeners.push(RosterEvent{Type: SessionStarted, Session: *s, Reconciled: reconciled})
+	r.mutex.Unlock()
+	r.listeners.deliver()
+}
+
+// left 记录玩家下线，since 不为nil时表示由对账发现，值为对账请求发出时的通知序号
+func (r *Roster) left(player subdto.PlayerDto, since *uint64) {
+	key := PlayerKey(player)
+	reconciled := since != nil
+	r.mutex.Lock()
+	if since == nil {
+		r.seq++
+		r.notified[key] = r.seq
+	} else if r.notified[key] > *since {
+		// 对账请求发出后收到过该玩家的通知，快照已经过时
+		r.mutex.Unlock()
+		return
+	}
+	s, ok := r.online[key]
+	if !ok {
+		r.mutex.Unlock()
+		return
+	}
+	r.end(key, s, r.clock.Now())
+	r.listeners.push(RosterEvent{Type: SessionEnded, Session: *s, Reconciled: reconciled})
+	r.mutex.Unlock()
+	r.listeners.deliver()
+}
+
+// connectionLost 连接断开，以当前时间结束所有在线会话
+// 断开前发出的对账请求不会再把这些玩家加回在线列表
+func (r *Roster) connectionLost() {
+	r.mutex.Lock()
+	now := r.clock.Now()
+	r.seq++
+	sessions := make([]*Session, 0, len(r.online))
+	for key, s := range r.online {
+		r.notified[key] = r.seq
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+	for _, s := range sessions {
+		r.end(PlayerKey(s.Player), s, now)
+		r.listeners.push(RosterEvent{Type: SessionEnded, Session: *s, Disconnected: true})
+	}
+	r.mutex.Unlock()
+	r.listeners.deliver()
+}
+
+// end 在at结束会话并移入历史记录，调用方需持有锁
+func (r *Roster) end(key string, s *Session, at time.Time) {
+	delete(r.online, key)
+	s.End = at
+	history := append(r.history[key], *s)
+	if len(history) > r.limit {
+		history = history[len(history)-r.limit:]
+	}
+	r.history[key] = history
+}
+
+// Online 返回在线玩家的会话，按上线时间排序
+func (r *Roster) Online() []Session {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := make([]Session, 0, len(r.online))
+	for _, s := range r.online {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Start.Before(list[j].Start)
+	})
+	return list
+}
+
+// Count 返回在线人数
+func (r *Roster) Count() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.online)
+}
+
+// IsOnline 玩家是否在线
+func (r *Roster) IsOnline(player subdto.PlayerDto) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.online[PlayerKey(player)]
+	return ok
+}
+
+// SessionLength 返回玩家当前会话的时长，不在线时返回false
+func (r *Roster) SessionLength(player subdto.PlayerDto) (time.Duration, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	s, ok := r.online[PlayerKey(player)]
+	if !ok {
+		return 0, false
+	}
+	return s.Duration(r.clock.Now()), true
+}
+
+// Sessions 返回玩家已结束的会话和当前会话，按时间排序
+func (r *Roster) Sessions(player subdto.PlayerDto) []Session {
+	key := PlayerKey(player)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	list := append([]Session(nil), r.history[key]...)
+	if s, ok := r.online[key]; ok {
+		list = append(list, *s)
+	}
+	return list
+}
+
+// Peak 返回跟踪以来的最高同时在线人数和达到的时间
+func (r *Roster) Peak() (int, time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.peak, r.peakAt
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"time"
+)
+
+// 常用的服务端任务，返回值可以直接作为 Job.Run
+
+// Save 保存世界并等待保存完成，等待使用调度器的时钟
+func Save(cli *mcmsmpgo.MsmpClient) func(ctx context.Context) error {
+	return cli.SaveAndWait
+}
+
+// Announce 广播系统消息
+func Announce(cli *mcmsmpgo.MsmpClient, message subdto.Message) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return cli.SendSystemMessage(ctx, subdto.SystemMessageDto{Message: message})
+	}
+}
+
+// ApplyGamerules 应用游戏规则配置，只修改与配置不同的规则
+func ApplyGamerules(cli *mcmsmpgo.MsmpClient, profile *mcmsmpgo.GameruleProfile) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := cli.ApplyGameruleProfile(ctx, profile, false)
+		return err
+	}
+}
+
+// Restart 倒计时after后停服，由外部的进程守护负责重新启动服务端
+// 倒计时使用调度器的时钟，调度器停止时倒计时会被取消并广播取消公告
+func Restart(cli *mcmsmpgo.MsmpClient, after time.Duration, opts mcmsmpgo.StopOptions) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return cli.ScheduleStop(ctx, after, opts)
+	}
+}
+
+// OnEach 对多个服务端执行同一个任务，返回第一个错误，其余服务端仍会执行
+func OnEach(clients []*mcmsmpgo.MsmpClient, task func(cli *mcmsmpgo.MsmpClient) func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var first error
+		for i, cli := range clients {
+			if err := task(cli)(ctx); err != nil && first == nil {
+				first = fmt.Errorf("server %d: %w", i, err)
+			}
+		}
+		return first
+	}
+}
@@ -0,0 +1,335 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"log"
+	"math/rand/v2"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MissedRunPolicy 调度器没能按时唤醒（例如进程挂起、系统休眠）时如何处理错过的执行
+type MissedRunPolicy int
+
+const (
+	// RunOnce 补执行一次，错过多次也只执行一次
+	RunOnce MissedRunPolicy = iota
+	// SkipMissed 跳过错过的执行，等待下一次计划时间
+	SkipMissed
+)
+
+// DefaultMissedTolerance 超过计划时间多久视为错过
+const DefaultMissedTolerance = time.Minute
+
+var (
+	// ErrDuplicateJob 已存在同名任务
+	ErrDuplicateJob = errors.New("job already exists")
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// Job 定时任务
+type Job struct {
+	// 任务名称，在调度器中唯一
+	Name string
+	// 执行计划
+	Schedule Schedule
+	// 任务函数，ctx在调度器停止时取消，并携带调度器的时钟（clock.FromContext）
+	Run func(ctx context.Context) error
+	// 在计划时间之后随机延迟 [0, Jitter) 执行，避免多个服务端同时执行
+	Jitter time.Duration
+	// 允许上一次执行未结束时开始新的执行，默认跳过
+	AllowOverlap bool
+	// 错过执行时的处理方式
+	MissedRuns MissedRunPolicy
+}
+
+// JobStatus 任务状态
+type JobStatus struct {
+	Name string
+	// 下一次执行的时间（包含随机延迟）
+	Next time.Time
+	// 最近一次执行的开始与结束时间
+	LastStart time.Time
+	LastEnd   time.Time
+	// 最近一次执行的错误，成功时为nil
+	LastError error
+	// 正在执行的数量
+	Running int
+	// 已完成的执行次数
+	Runs int
+	// 执行失败的次数（包括panic）
+	Failures int
+	// 因上一次执行未结束而跳过的次数
+	Overlapped int
+	// 因错过计划时间而跳过的次数
+	Missed int
+}
+
+// Config 调度器配置
+type Config struct {
+	// 时钟，默认为 clock.Real
+	Clock clock.Clock
+	// 超过计划时间多久视为错过，默认为 DefaultMissedTolerance
+	MissedTolerance time.Duration
+	// 任务出错时的上报函数，默认写入日志
+	ErrorHook func(name string, err error)
+}
+
+type entry struct {
+	job    Job
+	status JobStatus
+}
+
+// Scheduler 按计划执行任务，所有任务由一个协程调度，每次执行在新的协程中进行
+type Scheduler struct {
+	clock     clock.Clock
+	tolerance time.Duration
+	errorHook func(name string, err error)
+
+	mutex   sync.Mutex
+	jobs    map[string]*entry
+	running bool
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建调度器，config为nil时使用默认配置
+func New(config *Config) *Scheduler {
+	s := &Scheduler{
+		clock:     clock.Real{},
+		tolerance: DefaultMissedTolerance,
+		errorHook: func(name string, err error) {
+			log.Printf("scheduled job %s failed: %v", name, err)
+		},
+		jobs: make(map[string]*entry),
+		wake: make(chan struct{}, 1),
+	}
+	if config != nil {
+		if config.Clock != nil {
+			s.clock = config.Clock
+		}
+		if config.MissedTolerance > 0 {
+			s.tolerance = config.MissedTolerance
+		}
+		if config.ErrorHook != nil {
+			s.errorHook = config.ErrorHook
+		}
+	}
+	return s
+}
+
+// Add 添加任务，调度器运行中时立即生效
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errors.New("job name, schedule and run are required")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+	}
+	e := &entry{job: job, status: JobStatus{Name: job.Name}}
+	s.plan(e, s.clock.Now())
+	s.jobs[job.Name] = e
+	s.notify()
+	return nil
+}
+
+// Remove 移除任务，不会中断正在进行的执行
+func (s *Scheduler) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.jobs[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	delete(s.jobs, name)
+	s.notify()
+	return nil
+}
+
+// Status 返回任务状态
+func (s *Scheduler) Status(name string) (JobStatus, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return e.status, true
+}
+
+// Statuses 返回所有任务的状态，按下一次执行时间排序
+func (s *Scheduler) Statuses() []JobStatus {
+	s.mutex.Lock()
+	list := make([]JobStatus, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		list = append(list, e.status)
+	}
+	s.mutex.Unlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Next.Equal(list[j].Next) {
+			return list[i].Next.Before(list[j].Next)
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// RunNow 立即执行一次任务，不影响计划时间，仍然遵守重叠限制
+func (s *Scheduler) RunNow(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	if !s.running {
+		return errors.New("scheduler is not running")
+	}
+	s.start(e)
+	return nil
+}
+
+// Start 启动调度协程，ctx结束或调用 Stop 时停止
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.running {
+		return errors.New("scheduler already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.running = true
+	// 任务通过ctx使用调度器的时钟，客户端的等待和倒计时方法会优先使用它
+	s.ctx = clock.NewContext(ctx, s.clock)
+	s.cancel = cancel
+	s.stopped = make(chan struct{})
+	go s.loop(ctx, s.stopped)
+	return nil
+}
+
+// Stop 停止调度并取消正在进行的执行，等待它们结束
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	if !s.running {
+		s.mutex.Unlock()
+		return
+	}
+	s.running = false
+	s.cancel()
+	stopped := s.stopped
+	s.mutex.Unlock()
+	<-stopped
+	s.wg.Wait()
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// plan 计算任务在after之后的下一次执行时间，调用方需持有锁
+func (s *Scheduler) plan(e *entry, after time.Time) {
+	next := e.job.Schedule.Next(after)
+	if !next.IsZero() && e.job.Jitter > 0 {
+		next = next.Add(rand.N(e.job.Jitter))
+	}
+	e.status.Next = next
+}
+
+func (s *Scheduler) loop(ctx context.Context, stopped chan struct{}) {
+	defer close(stopped)
+	for {
+		s.mutex.Lock()
+		now := s.clock.Now()
+		var earliest time.Time
+		for _, e := range s.jobs {
+			if e.status.Next.IsZero() {
+				continue
+			}
+			if !e.status.Next.After(now) {
+				s.fire(e, now)
+			}
+			if !e.status.Next.IsZero() && (earliest.IsZero() || e.status.Next.Before(earliest)) {
+				earliest = e.status.Next
+			}
+		}
+		s.mutex.Unlock()
+
+		var timer clock.Timer
+		var expired <-chan time.Time
+		if !earliest.IsZero() {
+			timer = s.clock.NewTimer(earliest.Sub(now))
+			expired = timer.C()
+		}
+		select {
+		case <-expired:
+		case <-s.wake:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// fire 处理到期的任务并计算下一次执行时间，调用方需持有锁
+func (s *Scheduler) fire(e *entry, now time.Time) {
+	late := now.Sub(e.status.Next) > s.tolerance
+	s.plan(e, now)
+	if late && e.job.MissedRuns == SkipMissed {
+		e.status.Missed++
+		return
+	}
+	s.start(e)
+}
+
+// start 在新的协程中执行任务，调用方需持有锁
+func (s *Scheduler) start(e *entry) {
+	if e.status.Running > 0 && !e.job.AllowOverlap {
+		e.status.Overlapped++
+		return
+	}
+	e.status.Running++
+	e.status.LastStart = s.clock.Now()
+	s.wg.Add(1)
+	go func(ctx context.Context) {
+		defer s.wg.Done()
+		err := run(ctx, e.job)
+		s.mutex.Lock()
+		e.status.Running--
+		e.status.Runs++
+		e.status.LastEnd = s.clock.Now()
+		e.status.LastError = err
+		if err != nil {
+			e.status.Failures++
+		}
+		s.mutex.Unlock()
+		if err != nil {
+			s.errorHook(e.job.Name, err)
+		}
+	}(s.ctx)
+}
+
+// run 执行任务，将panic转换为错误
+func run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return job.Run(ctx)
+}
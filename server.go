@@ -1,5 +1,59 @@
 package mcmsmpgo
 
+import (
+	"context"
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+)
+
+// 服务端设置名称，对应 minecraft:serversettings/<name>
+const (
+	SettingAutosave                    = "autosave"
+	SettingDifficulty                  = "difficulty"
+	SettingEnforceAllowlist            = "enforce_allowlist"
+	SettingUseAllowlist                = "use_allowlist"
+	SettingMaxPlayers                  = "max_players"
+	SettingPauseWhenEmptySeconds       = "pause_when_empty_seconds"
+	SettingPlayerIdleTimeout           = "player_idle_timeout"
+	SettingAllowFlight                 = "allow_flight"
+	SettingMotd                        = "motd"
+	SettingSpawnProtectionRadius       = "spawn_protection_radius"
+	SettingForceGameMode               = "force_game_mode"
+	SettingGameMode                    = "game_mode"
+	SettingViewDistance                = "view_distance"
+	SettingSimulationDistance          = "simulation_distance"
+	SettingAcceptTransfers             = "accept_transfers"
+	SettingStatusHeartbeatInterval     = "status_heartbeat_interval"
+	SettingOperatorUserPermissionLevel = "operator_user_permission_level"
+	SettingHideOnlinePlayers           = "hide_online_players"
+	SettingStatusReplies               = "status_replies"
+	SettingEntityBroadcastRange        = "entity_broadcast_range"
+)
+
+// ServerSettingNames 协议中所有可读写的服务端设置
+var ServerSettingNames = []string{
+	SettingAutosave,
+	SettingDifficulty,
+	SettingEnforceAllowlist,
+	SettingUseAllowlist,
+	SettingMaxPlayers,
+	SettingPauseWhenEmptySeconds,
+	SettingPlayerIdleTimeout,
+	SettingAllowFlight,
+	SettingMotd,
+	SettingSpawnProtectionRadius,
+	SettingForceGameMode,
+	SettingGameMode,
+	SettingViewDistance,
+	SettingSimulationDistance,
+	SettingAcceptTransfers,
+	SettingStatusHeartbeatInterval,
+	SettingOperatorUserPermissionLevel,
+	SettingHideOnlinePlayers,
+	SettingStatusReplies,
+	SettingEntityBroadcastRange,
+}
+
 func (c *MsmpClient) ServerStatus() {
 	err := c.SendRequest("minecraft:server/status", nil)
 	if err != nil {
@@ -21,14 +75,11 @@ func (c *MsmpClient) ServerStop() {
 	}
 }
 
-func (c *MsmpClient) ServerSystemMessage(message string) {
-	param := map[string]string{
-		"message": message,
-	}
-	err := c.SendRequest("minecraft:server/system_message", param)
-	if err != nil {
-		return
+func (c *MsmpClient) ServerSystemMessage(message string) error {
+	param := subdto.SystemMessageDto{
+		Message: subdto.Message{Literal: message},
 	}
+	return c.SendRequest("minecraft:server/system_message", param)
 }
 
 func (c *MsmpClient) ServerSettingsGet(path string) {
@@ -49,3 +100,33 @@ func (c *MsmpClient) ServerSettingsSet(path string, value interface{}) {
 		return
 	}
 }
+
+// GetServerStatus 获取服务端状态并等待结果
+func (c *MsmpClient) GetServerStatus(ctx context.Context) (subdto.ServerState, error) {
+	return Call[subdto.ServerState](ctx, c, "minecraft:server/status", nil)
+}
+
+// SendSystemMessage 发送系统消息并等待结果，可以指定接收的玩家和是否显示在动作栏
+// 接收玩家的Id会被标准化，存在无效Id时不发送请求并返回错误
+func (c *MsmpClient) SendSystemMessage(ctx context.Context, message subdto.SystemMessageDto) error {
+	players, err := subdto.NormalizeAll(message.ReceivingPlayers)
+	if err != nil {
+		return err
+	}
+	message.ReceivingPlayers = players
+	_, err = CallRaw(ctx, c, "minecraft:server/system_message", message)
+	return err
+}
+
+// GetServerSetting 获取服务端设置的原始值
+func (c *MsmpClient) GetServerSetting(ctx context.Context, path string) (json.RawMessage, error) {
+	return CallRaw(ctx, c, "minecraft:serversettings/"+path, nil)
+}
+
+// SetServerSetting 修改服务端设置并等待结果，返回服务端设置后的原始值
+func (c *MsmpClient) SetServerSetting(ctx context.Context, path string, value interface{}) (json.RawMessage, error) {
+	param := map[string]interface{}{
+		"value": value,
+	}
+	return CallRaw(ctx, c, "minecraft:serversettings/"+path+"/set", param)
+}
@@ -0,0 +1,262 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"log"
+	"sync"
+	"time"
+)
+
+// StatusEventType 服务端状态变化事件类型
+type StatusEventType int
+
+const (
+	// StatusStarted 服务端启动完成（包括重启后）
+	StatusStarted StatusEventType = iota
+	// StatusStopped 服务端停止或正在停止
+	StatusStopped
+	// StatusVersionChanged 服务端版本变化
+	StatusVersionChanged
+	// StatusUnavailable 无法获取状态，通常是连接断开
+	StatusUnavailable
+)
+
+// StatusEvent 服务端状态变化事件
+type StatusEvent struct {
+	Type     StatusEventType
+	Previous StatusSnapshot
+	Current  StatusSnapshot
+}
+
+// StatusSnapshot 最近一次获取到的服务端状态
+type StatusSnapshot struct {
+	State subdto.ServerState
+	// 是否能获取到状态，连接断开时为false，此时State为最后一次获取到的状态
+	Available bool
+	// 最近一次更新的时间
+	UpdatedAt time.Time
+	// 最近一次更新来自心跳通知还是轮询
+	FromHeartbeat bool
+	// 观察到服务端启动的时间；开始跟踪时服务端已启动的，为第一次获取到状态的时间
+	StartedAt time.Time
+	// 跟踪以来观察到的重启次数；观察到服务端运行后，又观察到停止或连接断开再启动时计为一次重启，
+	// 第一次观察到启动（包括开始跟踪时服务端处于停止状态）不计，
+	// 连接断开期间无法区分短暂断线和重启，轮询失败（连接仍正常）后恢复则不计
+	Restarts int
+}
+
+// Uptime 服务端已运行的时间，未启动时为0
+func (s StatusSnapshot) Uptime(now time.Time) time.Duration {
+	if !s.Available || !s.State.Started || s.StartedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(s.StartedAt)
+}
+
+// StatusPollerConfig 状态轮询配置
+type StatusPollerConfig struct {
+	// 轮询间隔，默认为30秒；这段时间内收到过心跳通知时跳过轮询
+	Interval time.Duration
+	// 时钟，默认为客户端的时钟
+	Clock clock.Clock
+}
+
+// StatusPoller 跟踪服务端状态，优先使用状态心跳通知，没有心跳时定期轮询 minecraft:server/status
+type StatusPoller struct {
+	client   *MsmpClient
+	interval time.Duration
+	clock    clock.Clock
+
+	mutex    sync.Mutex
+	snapshot StatusSnapshot
+	seen     bool
+	// 是否观察到过服务端处于运行状态，之后的启动才算重启
+	ran bool
+	// 上一次运行之后观察到服务端停止或连接断开，下一次观察到已启动时视为启动（重启）
+	down      bool
+	listeners eventListeners[StatusEvent]
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewStatusPoller 创建状态轮询，config为nil时使用默认配置，调用 Start 后开始跟踪
+func NewStatusPoller(client *MsmpClient, config *StatusPollerConfig) *StatusPoller {
+	p := &StatusPoller{
+		client:   client,
+		interval: 30 * time.Second,
+		clock:    client.Clock(),
+	}
+	if config != nil {
+		if config.Interval > 0 {
+			p.interval = config.Interval
+		}
+		if config.Clock != nil {
+			p.clock = config.Clock
+		}
+	}
+	return p
+}
+
+// Start 订阅状态心跳和停止通知并立即轮询一次，ctx结束或调用 Stop 时停止
+func (p *StatusPoller) Start(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.cancel != nil {
+		p.mutex.Unlock()
+		return errors.New("status poller already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.stopped = make(chan struct{})
+	p.mutex.Unlock()
+
+	unsubscribeStatus := p.client.Subscribe(NotificationServerStatus, func(n *dto.MsmpNotification) {
+		var state subdto.ServerState
+		if err := n.DecodeParams(&state); err != nil {
+			log.Printf("status poller: invalid %s params: %v", n.Method, err)
+			return
+		}
+		p.update(state, true, true)
+	})
+	unsubscribeStopping := p.client.Subscribe(NotificationServerStopping, func(*dto.MsmpNotification) {
+		p.mutex.Lock()
+		state := p.snapshot.State
+		p.mutex.Unlock()
+		state.Started = false
+		p.update(state, true, true)
+	})
+
+	lost := p.client.ConnectionLost()
+	err := p.Poll(ctx)
+	go func() {
+		defer close(p.stopped)
+		defer unsubscribeStatus()
+		defer unsubscribeStopping()
+		ticker := p.clock.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lost:
+				p.connectionLost()
+				// 连接断开后 ConnectionLost 一直处于关闭状态，等到下一次轮询时再重新获取
+				lost = nil
+			case <-ticker.C():
+				if lost == nil {
+					lost = p.client.ConnectionLost()
+				}
+				p.mutex.Lock()
+				recent := p.snapshot.FromHeartbeat && p.snapshot.Available && p.clock.Now().Sub(p.snapshot.UpdatedAt) < p.interval
+				p.mutex.Unlock()
+				if recent {
+					continue
+				}
+				_ = p.Poll(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return err
+}
+
+// Stop 停止跟踪
+func (p *StatusPoller) Stop() {
+	p.mutex.Lock()
+	cancel, stopped := p.cancel, p.stopped
+	p.cancel = nil
+	p.mutex.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-stopped
+}
+
+// Poll 立即获取一次状态，失败时将状态标记为不可用，但不视为服务端停止
+func (p *StatusPoller) Poll(ctx context.Context) error {
+	pollCtx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+	state, err := p.client.GetServerStatus(pollCtx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.update(subdto.ServerState{}, false, false)
+		}
+		return err
+	}
+	p.update(state, true, false)
+	return nil
+}
+
+// Snapshot 返回最近的状态
+func (p *StatusPoller) Snapshot() StatusSnapshot {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.snapshot
+}
+
+// AddListener 注册事件监听，返回取消监听的函数
+// 事件按发生顺序逐个投递，监听函数可以调用 Snapshot，但不能阻塞
+func (p *StatusPoller) AddListener(fn func(StatusEvent)) (remove func()) {
+	return p.listeners.add(fn)
+}
+
+// connectionLost 连接断开，将状态标记为不可用，重新连接后观察到已启动时计为一次重启
+func (p *StatusPoller) connectionLost() {
+	p.mutex.Lock()
+	p.down = true
+	p.mutex.Unlock()
+	p.update(subdto.ServerState{}, false, false)
+}
+
+// update 更新状态并生成变化事件，available为false时保留上一次的状态
+func (p *StatusPoller) update(state subdto.ServerState, available, heartbeat bool) {
+	p.mutex.Lock()
+	now := p.clock.Now()
+	prev := p.snapshot
+	next := prev
+	next.UpdatedAt = now
+	next.Available = available
+	next.FromHeartbeat = heartbeat
+	if available {
+		next.State = state
+	}
+
+	// 轮询失败期间不改变运行状态，只有观察到停止或连接断开才会结束一次运行
+	running := p.seen && !p.down
+	var events []StatusEventType
+	switch {
+	case !available:
+		if !p.seen || prev.Available {
+			events = append(events, StatusUnavailable)
+		}
+	case next.State.Started && !running:
+		// 之前没有观察到运行状态时不算重启，例如开始跟踪时服务端处于停止状态
+		if p.ran {
+			next.Restarts++
+		}
+		next.StartedAt = now
+		p.down = false
+		p.ran = true
+		events = append(events, StatusStarted)
+	case !next.State.Started && (running || !p.seen):
+		next.StartedAt = time.Time{}
+		p.down = true
+		events = append(events, StatusStopped)
+	}
+	if available && p.seen && prev.State.Version != (subdto.Version{}) && prev.State.Version != next.State.Version {
+		events = append(events, StatusVersionChanged)
+	}
+	if available {
+		p.seen = true
+	}
+	p.snapshot = next
+	for _, t := range events {
+		p.listeners.push(StatusEvent{Type: t, Previous: prev, Current: next})
+	}
+	p.mutex.Unlock()
+	p.listeners.deliver()
+}
@@ -0,0 +1,147 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/text"
+	"sort"
+	"time"
+)
+
+// DefaultStopCheckpoints 默认的倒计时公告时间点
+var DefaultStopCheckpoints = []time.Duration{
+	10 * time.Minute,
+	5 * time.Minute,
+	time.Minute,
+	30 * time.Second,
+	10 * time.Second,
+	5 * time.Second,
+}
+
+// StopOptions 计划停服的选项
+type StopOptions struct {
+	// 剩余时间到达这些时间点时广播公告，为空时使用 DefaultStopCheckpoints
+	Checkpoints []time.Duration
+	// 生成倒计时公告，为nil时使用默认的公告
+	Countdown func(remaining time.Duration) subdto.Message
+	// 取消停服时广播的公告，为零值时使用默认的公告
+	CancelMessage subdto.Message
+	// 踢出玩家时显示的消息，为零值时使用服务端默认的断开消息
+	KickMessage subdto.Message
+}
+
+// formatRemaining 将剩余时间格式化为 "5 minutes" 这样的文本
+func formatRemaining(d time.Duration) string {
+	unit := func(n int64, name string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", name)
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return unit(int64(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return unit(int64(d/time.Minute), "minute")
+	case d >= time.Second:
+		return unit(int64(d.Round(time.Second)/time.Second), "second")
+	}
+	return d.String()
+}
+
+func defaultCountdown(remaining time.Duration) subdto.Message {
+	return text.Text("Server stopping in ").WithColor(text.Gold).
+		Append(text.Text(formatRemaining(remaining)).WithColor(text.Red).Bolded()).
+		Message()
+}
+
+var defaultCancelMessage = text.Text("Server stop cancelled").WithColor(text.Green).Message()
+
+// ScheduleStop 在after之后停服：
+// 按检查点广播倒计时，时间到后踢出所有玩家，保存世界并等待保存完成，
+// 最后停止服务端并等待停止通知或连接断开。保存无法确认时仍会停止服务端，之后返回包装了 ErrSaveUnconfirmed 的错误。
+// 倒计时期间ctx被取消时广播取消公告并返回ctx的错误；倒计时结束后ctx只限制等待时间。
+// 倒计时使用ctx携带的时钟（例如调度器的时钟），没有时使用客户端的时钟（NewClientConfig.Clock）。
+func (c *MsmpClient) ScheduleStop(ctx context.Context, after time.Duration, opts StopOptions) error {
+	checkpoints := opts.Checkpoints
+	if len(checkpoints) == 0 {
+		checkpoints = DefaultStopCheckpoints
+	}
+	checkpoints = append([]time.Duration(nil), checkpoints...)
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i] > checkpoints[j]
+	})
+	countdown := opts.Countdown
+	if countdown == nil {
+		countdown = defaultCountdown
+	}
+
+	deadline := c.clockFor(ctx).Now().Add(after)
+	announce := func(remaining time.Duration) error {
+		return c.SendSystemMessage(ctx, subdto.SystemMessageDto{Message: countdown(remaining)})
+	}
+	if after > 0 {
+		if err := announce(after); err != nil {
+			return fmt.Errorf("schedule stop: %w", err)
+		}
+	}
+	for _, checkpoint := range checkpoints {
+		if checkpoint <= 0 || checkpoint >= after {
+			continue
+		}
+		if err := c.sleepUntil(ctx, deadline.Add(-checkpoint)); err != nil {
+			return c.cancelStop(err, opts)
+		}
+		if err := announce(checkpoint); err != nil {
+			return fmt.Errorf("schedule stop: %w", err)
+		}
+	}
+	if err := c.sleepUntil(ctx, deadline); err != nil {
+		return c.cancelStop(err, opts)
+	}
+
+	if _, err := c.KickWhere(ctx, func(subdto.PlayerDto) bool { return true }, opts.KickMessage); err != nil {
+		return fmt.Errorf("schedule stop: %w", err)
+	}
+	// 服务端停止时也会保存世界，无法确认的保存不阻止停止，但停止后仍返回 ErrSaveUnconfirmed
+	saveErr := c.SaveAndWait(ctx)
+	if saveErr != nil && !errors.Is(saveErr, ErrSaveUnconfirmed) {
+		return fmt.Errorf("schedule stop: %w", saveErr)
+	}
+	if err := c.StopAndWait(ctx); err != nil {
+		return fmt.Errorf("schedule stop: %w", errors.Join(saveErr, err))
+	}
+	if saveErr != nil {
+		return fmt.Errorf("schedule stop: server stopped, but %w", saveErr)
+	}
+	return nil
+}
+
+// sleepUntil 按ctx携带的时钟或客户端的时钟等待到t，ctx结束时返回ctx的错误
+func (c *MsmpClient) sleepUntil(ctx context.Context, t time.Time) error {
+	clk := c.clockFor(ctx)
+	timer := clk.NewTimer(t.Sub(clk.Now()))
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelStop 广播取消公告，ctx已经结束，公告使用独立的超时
+func (c *MsmpClient) cancelStop(cause error, opts StopOptions) error {
+	message := opts.CancelMessage
+	if message.Literal == "" && message.Translatable == "" {
+		message = defaultCancelMessage
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.SendSystemMessage(ctx, subdto.SystemMessageDto{Message: message}); err != nil {
+		return fmt.Errorf("schedule stop cancelled: %w", errors.Join(cause, err))
+	}
+	return fmt.Errorf("schedule stop cancelled: %w", cause)
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/cmd"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"strings"
+	"testing"
+)
+
+// fakeManagedServer 注册备份涉及的全部只读方法
+func fakeManagedServer(t *testing.T, motd string, keepInventory string) *fakeServer {
+	f := newFakeServer(t)
+	f.Result("minecraft:allowlist", []subdto.PlayerDto{subdto.OfflinePlayer("Steve")})
+	f.Result("minecraft:bans", []subdto.UserBanDto{})
+	f.Result("minecraft:ip_bans", []subdto.IpBanDTO{{Ip: "10.0.0.1", Reason: "Spam"}})
+	f.Result("minecraft:operators", []subdto.OperatorDto{{PermissionLevel: 4, Player: subdto.OfflinePlayer("Notch")}})
+	f.Result("minecraft:gamerules", []subdto.TypedRule{
+		{Key: "keepInventory", Value: keepInventory, Type: "boolean"},
+		{Key: "randomTickSpeed", Value: "3", Type: "integer"},
+	})
+	for _, name := range mcmsmpgo.ServerSettingNames {
+		switch name {
+		case mcmsmpgo.SettingMotd:
+			f.Result("minecraft:serversettings/"+name, motd)
+		case mcmsmpgo.SettingMaxPlayers:
+			f.Result("minecraft:serversettings/"+name, 20)
+		case mcmsmpgo.SettingEntityBroadcastRange:
+			// 模拟旧版本服务端不支持的设置
+		default:
+			f.Result("minecraft:serversettings/"+name, false)
+		}
+	}
+	f.Handle("minecraft:serversettings/motd/set", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return params, nil
+	})
+	f.Result("minecraft:gamerules/update", []subdto.TypedRule{})
+	return f
+}
+
+func TestBackupRestore(t *testing.T) {
+	f := fakeManagedServer(t, "Hello", "false")
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	doc, err := cli.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != mcmsmpgo.BackupVersion || len(doc.Allowlist) != 1 || len(doc.IpBans) != 1 || len(doc.Gamerules) != 2 {
+		t.Fatalf("unexpected backup %+v", doc)
+	}
+	if string(doc.Settings[mcmsmpgo.SettingMotd]) != `"Hello"` || len(doc.Settings) != len(mcmsmpgo.ServerSettingNames)-1 {
+		t.Fatalf("unexpected settings %v", doc.Settings)
+	}
+
+	var buf bytes.Buffer
+	if err := mcmsmpgo.WriteBackup(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := mcmsmpgo.ReadBackup(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Settings[mcmsmpgo.SettingMotd] = json.RawMessage(`"Maintenance"`)
+	loaded.Gamerules[0].Value = "true"
+
+	report, err := cli.Restore(ctx, loaded, mcmsmpgo.RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Allowlist.Empty() || !report.Operators.Empty() || len(report.Gamerules) != 1 || len(report.Settings) != 1 {
+		t.Fatalf("unexpected preview %+v", report)
+	}
+	if len(f.CallsOf("minecraft:serversettings/motd/set")) != 0 {
+		t.Fatal("dry run must not modify the server")
+	}
+
+	report, err = cli.Restore(ctx, loaded, mcmsmpgo.RestoreOptions{Sections: []mcmsmpgo.BackupSection{mcmsmpgo.SectionSettings}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Allowlist != nil || report.Gamerules != nil {
+		t.Fatal("only the settings section should be restored")
+	}
+	// 已恢复但没有差异的部分为空而不是nil
+	report, err = cli.Restore(ctx, doc, mcmsmpgo.RestoreOptions{Sections: []mcmsmpgo.BackupSection{mcmsmpgo.SectionGamerules}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Gamerules == nil || len(report.Gamerules) != 0 || report.Settings != nil {
+		t.Fatalf("unexpected gamerules report %+v", report)
+	}
+	calls := f.CallsOf("minecraft:serversettings/motd/set")
+	if len(calls) != 1 || string(calls[0].Params.(json.RawMessage)) != `[{"value":"Maintenance"}]` {
+		t.Fatalf("unexpected motd calls %+v", calls)
+	}
+	if len(f.CallsOf("minecraft:gamerules/update")) != 0 {
+		t.Fatal("gamerules must not be restored")
+	}
+}
+
+func TestRestoreUnsupportedSettings(t *testing.T) {
+	f := fakeManagedServer(t, "Hello", "false")
+	// 没有值的设置不应写入快照
+	f.Result("minecraft:serversettings/"+mcmsmpgo.SettingMaxPlayers, nil)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	doc, err := cli.Backup(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Settings[mcmsmpgo.SettingMaxPlayers]; ok {
+		t.Fatalf("null setting stored in backup: %s", doc.Settings[mcmsmpgo.SettingMaxPlayers])
+	}
+
+	// 来自其他服务端的快照中包含目标不支持的设置
+	doc.Settings[mcmsmpgo.SettingEntityBroadcastRange] = json.RawMessage(`100`)
+	doc.Settings[mcmsmpgo.SettingMotd] = json.RawMessage(`"Maintenance"`)
+	report, err := cli.Restore(ctx, doc, mcmsmpgo.RestoreOptions{Sections: []mcmsmpgo.BackupSection{mcmsmpgo.SectionSettings}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.UnsupportedSettings) != 1 || report.UnsupportedSettings[0] != mcmsmpgo.SettingEntityBroadcastRange {
+		t.Fatalf("unsupported = %v", report.UnsupportedSettings)
+	}
+	if len(report.Settings) != 1 || len(f.CallsOf("minecraft:serversettings/motd/set")) != 1 {
+		t.Fatalf("unexpected settings report %+v", report.Settings)
+	}
+	var out strings.Builder
+	cmd.PrintReport(&out, report)
+	if !strings.Contains(out.String(), "! "+mcmsmpgo.SettingEntityBroadcastRange+": unsupported by target") {
+		t.Fatalf("report output:\n%s", out.String())
+	}
+}
+
+func TestServerDiff(t *testing.T) {
+	source := newTestClient(t, fakeManagedServer(t, "Old world", "true"), nil)
+	target := newTestClient(t, fakeManagedServer(t, "New hardware", "false"), nil)
+
+	report, err := cmd.ServerDiff(testContext(t), source, target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	cmd.PrintReport(&buf, report)
+	out := buf.String()
+	for _, want := range []string{
+		"== allowlist ==\n  (no changes)\n",
+		"== gamerules ==\n~ keepInventory: false -> true\n",
+		"== settings ==\n~ motd: \"New hardware\" -> \"Old world\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("diff output missing %q:\n%s", want, out)
+		}
+	}
+}
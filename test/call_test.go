@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"testing"
+	"time"
+)
+
+// newTestClient 创建连接到模拟服务端的客户端
+func newTestClient(t *testing.T, f *fakeServer, config *mcmsmpgo.NewClientConfig) *mcmsmpgo.MsmpClient {
+	if config == nil {
+		config = &mcmsmpgo.NewClientConfig{}
+	}
+	cli := mcmsmpgo.NewMsmpClient(f.URL(), "secret", config)
+	if err := cli.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cli.Disconnect()
+	})
+	return cli
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestCall(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:players", []subdto.PlayerDto{{Id: "853c80ef-3c37-49fd-aa49-938b674adae6", Name: "jeb_"}})
+	f.Handle("mymod:echo", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return params, nil
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	players, err := mcmsmpgo.Call[[]subdto.PlayerDto](ctx, cli, "minecraft:players", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 1 || players[0].Name != "jeb_" {
+		t.Fatalf("unexpected players %+v", players)
+	}
+
+	raw, err := mcmsmpgo.CallRaw(ctx, cli, "mymod:echo", dto.Named{"value": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"value":3}` {
+		t.Fatalf("named params = %s", raw)
+	}
+	raw, err = mcmsmpgo.CallRaw(ctx, cli, "mymod:echo", dto.Positional{"a", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `["a",1]` {
+		t.Fatalf("positional params = %s", raw)
+	}
+
+	_, err = mcmsmpgo.CallRaw(ctx, cli, "mymod:missing", nil)
+	var rpcErr *dto.MsmpResponseError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("expected method not found error, got %v", err)
+	}
+}
+
+func TestPendingCallFanOut(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/status", subdto.ServerState{Started: true, Version: subdto.Version{Name: "1.21.9", Protocol: 773}})
+	f.Result("minecraft:players", []subdto.PlayerDto{{Name: "jeb_"}, {Name: "Dinnerbone"}})
+	f.Result("minecraft:gamerules", []subdto.TypedRule{{Key: "keepInventory", Value: "true", Type: "boolean"}})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	status, err := cli.Go("minecraft:server/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players, err := cli.Go("minecraft:players", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := cli.Go("minecraft:gamerules", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ID == players.ID || players.ID == rules.ID {
+		t.Fatal("request ids must be distinct")
+	}
+	if err := mcmsmpgo.WaitAll(ctx, status, players, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	var state subdto.ServerState
+	var list []subdto.PlayerDto
+	var typed []subdto.TypedRule
+	if err := status.Decode(ctx, &state); err != nil {
+		t.Fatal(err)
+	}
+	if err := players.Decode(ctx, &list); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.Decode(ctx, &typed); err != nil {
+		t.Fatal(err)
+	}
+	if !state.Started || len(list) != 2 || typed[0].Key != "keepInventory" {
+		t.Fatalf("unexpected results %+v %+v %+v", state, list, typed)
+	}
+
+	missing, err := cli.Go("minecraft:missing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mcmsmpgo.WaitAll(ctx, missing); err == nil {
+		t.Fatal("expected error for unknown method")
+	}
+}
+
+func TestCallConnectionLost(t *testing.T) {
+	f := newFakeServer(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	f.Handle("mymod:hang", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+	cli := newTestClient(t, f, nil)
+	cli.SetAutoReconnect(false)
+
+	// 没有超时的ctx，只能靠连接断开结束等待
+	result := make(chan error, 1)
+	go func() {
+		_, err := mcmsmpgo.CallRaw(context.Background(), cli, "mymod:hang", nil)
+		result <- err
+	}()
+	<-started
+	legacy := make(chan dto.MsmpResponse, 1)
+	if err := cli.SendRequestWithCallback("mymod:hang", nil, func(_ *dto.MsmpRequest, response dto.MsmpResponse) {
+		legacy <- response
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Drop()
+	select {
+	case err := <-result:
+		if !errors.Is(err, mcmsmpgo.ErrConnectionLost) {
+			t.Fatalf("err = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("call not failed after connection lost")
+	}
+	select {
+	case response := <-legacy:
+		if response.IsSuccess() || response.GetError().Message != mcmsmpgo.ErrConnectionLost.Error() {
+			t.Fatalf("response = %+v", response)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not called after connection lost")
+	}
+
+	if _, err := cli.Go("mymod:hang", nil); err == nil {
+		t.Fatal("expected error when not connected")
+	}
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	tickers []*fakeTicker
+	changed chan struct{}
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+type fakeTicker struct {
+	clock  *fakeClock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, changed: make(chan struct{}, 1)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) clock.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.notifyChanged()
+	return t
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.tickers = append(c.tickers, t)
+	c.notifyChanged()
+	return t
+}
+
+func (c *fakeClock) notifyChanged() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, other := range t.clock.tickers {
+		if other == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Advance 推进时间并触发到期的定时器，与 time.Ticker 一样，接收方来不及读取时丢弃多余的触发
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			remaining = append(remaining, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = remaining
+	for _, t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+// WaitForTimer 等待有定时器在等待
+func (c *fakeClock) WaitForTimer(t *testing.T) {
+	c.WaitForTimers(t, 1)
+}
+
+// WaitForTimers 等待至少n个定时器在等待
+func (c *fakeClock) WaitForTimers(t *testing.T, n int) {
+	c.waitFor(t, "timer", func() bool { return len(c.timers) >= n })
+}
+
+// WaitForTicker 等待有周期定时器在运行
+func (c *fakeClock) WaitForTicker(t *testing.T) {
+	c.waitFor(t, "ticker", func() bool { return len(c.tickers) > 0 })
+}
+
+func (c *fakeClock) waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		c.mutex.Lock()
+		ok := cond()
+		c.mutex.Unlock()
+		if ok {
+			return
+		}
+		select {
+		case <-c.changed:
+		case <-time.After(time.Millisecond):
+		case <-deadline:
+			t.Fatalf("no %s registered", what)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"github.com/CycleZero/mc-msmp-go/container"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/iface"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// benchGoRoutineNum 与 start_test.go 中的并发发送协程数保持一致
+const benchGoRoutineNum = 32
+
+func noopCallback(*dto.MsmpRequest, dto.MsmpResponse) {}
+
+// benchContainer 模拟客户端的使用方式：多个协程并发注册请求，
+// 单个读协程（对应 readMessages）按到达顺序匹配响应
+func benchContainer(b *testing.B, c iface.MessageContainer) {
+	var requestID atomic.Int64
+	ids := make(chan int, 4096)
+	readerDone := make(chan struct{})
+
+	go func() {
+		for id := range ids {
+			if _, err := c.NewResponse(&dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: id}); err != nil {
+				b.Error(err)
+			}
+		}
+		close(readerDone)
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var wg sync.WaitGroup
+	for r := 0; r < benchGoRoutineNum; r++ {
+		wg.Add(1)
+		go func(rid int) {
+			defer wg.Done()
+			total := b.N / benchGoRoutineNum
+			if rid < b.N%benchGoRoutineNum {
+				total++
+			}
+			for i := 0; i < total; i++ {
+				id := int(requestID.Add(1))
+				request := dto.NewMsmpRequest(id, "minecraft:server/status", nil)
+				if err := c.AddRequestWithHandler(&request, noopCallback); err != nil {
+					b.Error(err)
+					return
+				}
+				// 与 GetWaitingNum 等只读调用混合，贴近监控场景
+				if i%64 == 0 {
+					_, _ = c.GetWaitingNum()
+				}
+				ids <- id
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(ids)
+	<-readerDone
+	b.StopTimer()
+
+	if n, _ := c.GetWaitingNum(); n != 0 {
+		b.Fatalf("waiting requests left: %d", n)
+	}
+}
+
+// benchContainerParallel 每个协程自行注册并立即匹配响应，只衡量锁竞争
+func benchContainerParallel(b *testing.B, c iface.MessageContainer) {
+	var requestID atomic.Int64
+	b.ReportAllocs()
+	b.SetParallelism(benchGoRoutineNum)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := int(requestID.Add(1))
+			request := dto.NewMsmpRequest(id, "minecraft:server/status", nil)
+			if err := c.AddRequestWithHandler(&request, noopCallback); err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := c.NewResponse(&dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: id}); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkMapMessageContainer(b *testing.B) {
+	benchContainer(b, container.NewMapMessageContainer())
+}
+
+func BenchmarkShardedMessageContainer(b *testing.B) {
+	benchContainer(b, container.NewShardedMessageContainer(container.DefaultShardNum))
+}
+
+func BenchmarkMapMessageContainerParallel(b *testing.B) {
+	benchContainerParallel(b, container.NewMapMessageContainer())
+}
+
+func BenchmarkShardedMessageContainerParallel(b *testing.B) {
+	benchContainerParallel(b, container.NewShardedMessageContainer(container.DefaultShardNum))
+}
+
+func TestShardedMessageContainer(t *testing.T) {
+	c := container.NewShardedMessageContainer(5)
+	for id := 1; id <= 100; id++ {
+		request := dto.NewMsmpRequest(id, "minecraft:players", nil)
+		if err := c.AddRequest(&request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	request := dto.NewMsmpRequest(7, "minecraft:players", nil)
+	if err := c.AddRequest(&request); err == nil {
+		t.Fatal("expected duplicate request error")
+	}
+	if n, _ := c.GetWaitingNum(); n != 100 {
+		t.Fatalf("waiting num = %d, want 100", n)
+	}
+	if err := c.CancelRequest(3); err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.NewResponse(&dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Request.ID != 42 || p.Response.GetID() != 42 {
+		t.Fatalf("unexpected pair %+v", p)
+	}
+	if _, err := c.NewResponse(&dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: 42}); err == nil {
+		t.Fatal("expected no waiting request error")
+	}
+	list, _ := c.GetWaitingRequests()
+	if len(list) != 98 {
+		t.Fatalf("waiting requests = %d, want 98", len(list))
+	}
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/executor"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolExecutorOrdering(t *testing.T) {
+	for _, ordering := range []executor.Ordering{executor.GlobalFIFO, executor.PerMethodFIFO} {
+		e := executor.NewPoolExecutor(executor.PoolConfig{Workers: 4, QueueSize: 8, Ordering: ordering})
+		var mutex sync.Mutex
+		seen := make(map[string][]int)
+		for id := 1; id <= 200; id++ {
+			method := "minecraft:players"
+			if id%3 == 0 {
+				method = "minecraft:server/status"
+			}
+			request := dto.NewMsmpRequest(id, method, nil)
+			e.Execute(&dto.MessagePair{
+				Id:      id,
+				Request: &request,
+				Callback: func(request *dto.MsmpRequest, _ dto.MsmpResponse) {
+					mutex.Lock()
+					seen[request.Method] = append(seen[request.Method], request.ID)
+					mutex.Unlock()
+				},
+			})
+		}
+		e.Close()
+		for method, ids := range seen {
+			for i := 1; i < len(ids); i++ {
+				if ids[i] < ids[i-1] {
+					t.Fatalf("ordering %d: %s out of order: %v", ordering, method, ids)
+				}
+			}
+		}
+		if stats := e.Stats(); stats.Completed != 200 || stats.QueueDepth != 0 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	}
+}
+
+func TestPoolExecutorPanicRecovery(t *testing.T) {
+	var reported error
+	e := executor.NewPoolExecutor(executor.PoolConfig{
+		Ordering: executor.GlobalFIFO,
+		ErrorHook: func(_ *dto.MessagePair, err error) {
+			reported = err
+		},
+	})
+	ran := false
+	e.Execute(&dto.MessagePair{Id: 1, Callback: func(*dto.MsmpRequest, dto.MsmpResponse) {
+		panic("boom")
+	}})
+	e.Execute(&dto.MessagePair{Id: 2, Callback: func(*dto.MsmpRequest, dto.MsmpResponse) {
+		ran = true
+	}})
+	e.Close()
+
+	var panicErr *executor.PanicError
+	if !errors.As(reported, &panicErr) || panicErr.Value != "boom" {
+		t.Fatalf("expected panic to be reported, got %v", reported)
+	}
+	if !ran {
+		t.Fatal("callback after panic did not run")
+	}
+	if stats := e.Stats(); stats.Panics != 1 || stats.Completed != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolExecutorAfterClose(t *testing.T) {
+	var reported error
+	var e *executor.PoolExecutor
+	e = executor.NewPoolExecutor(executor.PoolConfig{
+		ErrorHook: func(_ *dto.MessagePair, err error) {
+			reported = err
+			// 上报时不持有锁，再次关闭不会死锁
+			e.Close()
+		},
+	})
+	e.Close()
+	ran := false
+	e.Execute(&dto.MessagePair{Id: 1, Callback: func(*dto.MsmpRequest, dto.MsmpResponse) {
+		ran = true
+	}})
+	if ran || !errors.Is(reported, executor.ErrExecutorClosed) {
+		t.Fatalf("ran = %v, reported = %v", ran, reported)
+	}
+	if stats := e.Stats(); stats.QueueDepth != 0 || stats.Completed != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestClientExecutor(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/status", map[string]bool{"started": true})
+	reported := make(chan error, 1)
+	e := executor.NewPoolExecutor(executor.PoolConfig{
+		Ordering: executor.GlobalFIFO,
+		ErrorHook: func(_ *dto.MessagePair, err error) {
+			reported <- err
+		},
+	})
+	defer e.Close()
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{
+		Handler: func(*dto.MsmpRequest, dto.MsmpResponse) {
+			panic("handler bug")
+		},
+		Executor: e,
+	})
+
+	cli.ServerStatus()
+	select {
+	case err := <-reported:
+		var panicErr *executor.PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("panic was not reported")
+	}
+
+	// 读协程仍在工作
+	if _, err := mcmsmpgo.CallRaw(testContext(t), cli, "minecraft:server/status", nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeMethod 模拟服务端方法，返回结果或错误
+type fakeMethod func(params json.RawMessage) (interface{}, *dto.MsmpResponseError)
+
+// fakeServer 本地模拟的MSMP服务端，仅用于测试
+type fakeServer struct {
+	server  *httptest.Server
+	mutex   sync.Mutex
+	methods map[string]fakeMethod
+	calls   []dto.MsmpRequest
+	conns   []*websocket.Conn
+	writeMu sync.Mutex
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{methods: make(map[string]fakeMethod)}
+	upgrader := websocket.Upgrader{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.mutex.Lock()
+		f.conns = append(f.conns, conn)
+		f.mutex.Unlock()
+		go f.serve(conn)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeServer) URL() string {
+	return "ws" + strings.TrimPrefix(f.server.URL, "http")
+}
+
+func (f *fakeServer) Handle(method string, fn fakeMethod) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.methods[method] = fn
+}
+
+// Result 注册返回固定结果的方法
+func (f *fakeServer) Result(method string, result interface{}) {
+	f.Handle(method, func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return result, nil
+	})
+}
+
+// Calls 返回服务端收到的请求
+func (f *fakeServer) Calls() []dto.MsmpRequest {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]dto.MsmpRequest(nil), f.calls...)
+}
+
+// CallsOf 返回指定方法收到的请求
+func (f *fakeServer) CallsOf(method string) []dto.MsmpRequest {
+	var list []dto.MsmpRequest
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// Notify 向所有连接推送通知
+func (f *fakeServer) Notify(method string, params ...interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if len(params) > 0 {
+		msg["params"] = params
+	}
+	f.mutex.Lock()
+	conns := append([]*websocket.Conn(nil), f.conns...)
+	f.mutex.Unlock()
+	for _, conn := range conns {
+		f.write(conn, msg)
+	}
+}
+
+func (f *fakeServer) Close() {
+	f.mutex.Lock()
+	for _, conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.mutex.Unlock()
+	f.server.Close()
+}
+
+// Drop 断开所有现有连接，服务端继续接受新的连接
+func (f *fakeServer) Drop() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, conn := range f.conns {
+		_ = conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeServer) write(conn *websocket.Conn, v interface{}) {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	_ = conn.WriteJSON(v)
+}
+
+func (f *fakeServer) serve(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var request struct {
+			dto.MsmpRequest
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(data, &request); err != nil {
+			continue
+		}
+		request.MsmpRequest.Params = request.Params
+		f.mutex.Lock()
+		f.calls = append(f.calls, request.MsmpRequest)
+		fn, ok := f.methods[request.Method]
+		f.mutex.Unlock()
+
+		if request.ID == 0 {
+			continue
+		}
+		if !ok {
+			f.write(conn, dto.MsmpResponseFailure{JSONRPC: "2.0", ID: request.ID, Error: dto.MsmpResponseError{
+				Code:    -32601,
+				Message: "Method not found",
+			}})
+			continue
+		}
+		result, rpcErr := fn(request.Params)
+		if rpcErr != nil {
+			f.write(conn, dto.MsmpResponseFailure{JSONRPC: "2.0", ID: request.ID, Error: *rpcErr})
+			continue
+		}
+		f.write(conn, dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: request.ID, Result: result})
+	}
+}
@@ -0,0 +1,216 @@
+package test
+
+import (
+	"encoding/json"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidateGamerule(t *testing.T) {
+	valid := []subdto.TypedRule{
+		{Key: "keepInventory", Value: "true"},
+		{Key: "randomTickSpeed", Value: "10", Type: "integer"},
+		{Key: "mymod:doSomething", Value: "false", Type: "boolean"},
+	}
+	for _, r := range valid {
+		if err := mcmsmpgo.ValidateGamerule(r); err != nil {
+			t.Fatalf("%+v: %v", r, err)
+		}
+	}
+	invalid := []subdto.TypedRule{
+		{Key: "keepInventory", Value: "True"},
+		{Key: "randomTickSpeed", Value: "fast"},
+		{Key: "mymod:doSomething", Value: "false"},
+		// 取值合法但声明的类型与注册表不一致
+		{Key: "randomTickSpeed", Value: "1", Type: "boolean"},
+	}
+	for _, r := range invalid {
+		if err := mcmsmpgo.ValidateGamerule(r); err == nil {
+			t.Fatalf("%+v: expected validation error", r)
+		}
+	}
+
+	// 不等待结果的接口同样先校验，失败时不发送请求
+	f := newFakeServer(t)
+	cli := newTestClient(t, f, nil)
+	if err := cli.GamerulesUpdate(invalid[:1]); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if n := len(f.Calls()); n != 0 {
+		t.Fatalf("sent %d requests with invalid rules", n)
+	}
+}
+
+func TestGameruleMap(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:gamerules", []subdto.TypedRule{
+		{Key: "keepInventory", Value: "false", Type: "boolean"},
+		{Key: "randomTickSpeed", Value: "3", Type: "integer"},
+		{Key: "mymod:spawnRate", Value: "7", Type: "integer"},
+	})
+	f.Result("minecraft:gamerules/update", []subdto.TypedRule{})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	rules, err := cli.GetGameruleMap(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := rules.Bool("keepInventory"); err != nil || v {
+		t.Fatalf("keepInventory = %v, %v", v, err)
+	}
+	if v, err := rules.Int("mymod:spawnRate"); err != nil || v != 7 {
+		t.Fatalf("mymod:spawnRate = %v, %v", v, err)
+	}
+	rules.SetBool("keepInventory", true)
+	if _, err := cli.UpdateGamerules(ctx, rules.Rules()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.SetKeepInventory(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.UpdateGamerules(ctx, []subdto.TypedRule{{Key: "keepInventory", Value: "True"}}); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if n := len(f.CallsOf("minecraft:gamerules/update")); n != 2 {
+		t.Fatalf("gamerules/update called %d times", n)
+	}
+}
+
+// statefulGamerules 模拟会保存修改结果的游戏规则接口
+func statefulGamerules(f *fakeServer, rules []subdto.TypedRule) {
+	var mutex sync.Mutex
+	f.Handle("minecraft:gamerules", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]subdto.TypedRule(nil), rules...), nil
+	})
+	f.Handle("minecraft:gamerules/update", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		var args [][]subdto.TypedRule
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &dto.MsmpResponseError{Code: -32602, Message: err.Error()}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, u := range args[0] {
+			for i := range rules {
+				if rules[i].Key == u.Key {
+					rules[i].Value = u.Value
+				}
+			}
+		}
+		return args[0], nil
+	})
+}
+
+func TestGameruleProfile(t *testing.T) {
+	profiles, err := mcmsmpgo.ReadGameruleProfiles(strings.NewReader(`{
+		"event night": {"keepInventory": true, "doMobSpawning": false, "randomTickSpeed": 3},
+		"survival default": {"keepInventory": "false", "doMobSpawning": "true"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mcmsmpgo.ReadGameruleProfiles(strings.NewReader(`{"bad": {"keepInventory": "True"}}`)); err == nil {
+		t.Fatal("expected invalid profile to be rejected")
+	}
+
+	f := newFakeServer(t)
+	statefulGamerules(f, []subdto.TypedRule{
+		{Key: "keepInventory", Value: "false", Type: "boolean"},
+		{Key: "doMobSpawning", Value: "true", Type: "boolean"},
+		{Key: "randomTickSpeed", Value: "3", Type: "integer"},
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	result, err := cli.ApplyGameruleProfile(ctx, profiles["event night"], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 2 {
+		t.Fatalf("expected only changed rules, got %+v", result.Changes)
+	}
+	rules, _ := cli.GetGameruleMap(ctx)
+	if v, _ := rules.Bool("keepInventory"); !v {
+		t.Fatal("profile was not applied")
+	}
+
+	if _, err := cli.RevertGameruleProfile(ctx, result); err != nil {
+		t.Fatal(err)
+	}
+	rules, _ = cli.GetGameruleMap(ctx)
+	if v, _ := rules.Bool("keepInventory"); v {
+		t.Fatal("profile was not reverted")
+	}
+	if v, _ := rules.Bool("doMobSpawning"); !v {
+		t.Fatal("profile was not reverted")
+	}
+
+	// 先写入临时文件再重命名，不留下临时文件
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profiles.json")
+	if err := mcmsmpgo.SaveGameruleProfiles(path, profiles); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("unexpected files %v", entries)
+	}
+	if loaded, err := mcmsmpgo.LoadGameruleProfiles(path); err != nil || len(loaded) != 2 {
+		t.Fatalf("loaded %v, %v", loaded, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Fatalf("unexpected mode %v, %v", info.Mode(), err)
+	}
+
+	// 覆盖已有文件时保留原文件的权限
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := mcmsmpgo.SaveGameruleProfiles(path, profiles); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("unexpected mode %v, %v", info.Mode(), err)
+	}
+}
+
+func TestGameruleProfileModdedRule(t *testing.T) {
+	// 模组规则只有字符串值，类型在应用时从服务端获取
+	profiles, err := mcmsmpgo.ReadGameruleProfiles(strings.NewReader(`{
+		"modded": {"mymod:spawnRate": "5", "mymod:unknown": "x"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFakeServer(t)
+	statefulGamerules(f, []subdto.TypedRule{
+		{Key: "mymod:spawnRate", Value: "1", Type: "integer"},
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	// 服务端没有的模组规则无法确定类型
+	if _, err := cli.ApplyGameruleProfile(ctx, profiles["modded"], false); err == nil {
+		t.Fatal("expected error for rule unknown to the server")
+	}
+	profiles["modded"].Rules = profiles["modded"].Rules[:1]
+	result, err := cli.ApplyGameruleProfile(ctx, profiles["modded"], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].After.Type != "integer" {
+		t.Fatalf("unexpected changes %+v", result.Changes)
+	}
+	rules, _ := cli.GetGameruleMap(ctx)
+	if v, _ := rules.Int("mymod:spawnRate"); v != 5 {
+		t.Fatalf("mymod:spawnRate = %d", v)
+	}
+}
@@ -0,0 +1,325 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/text"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statefulSettings 注册可读写的服务端设置，返回读取当前值的函数
+func statefulSettings(f *fakeServer, initial map[string]interface{}) func(name string) string {
+	var mutex sync.Mutex
+	values := make(map[string]json.RawMessage, len(initial))
+	for name, v := range initial {
+		values[name], _ = json.Marshal(v)
+		f.Handle("minecraft:serversettings/"+name, func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return values[name], nil
+		})
+		f.Handle("minecraft:serversettings/"+name+"/set", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+			var args []struct {
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+				return nil, &dto.MsmpResponseError{Code: -32602, Message: "invalid params"}
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			values[name] = args[0].Value
+			return args[0].Value, nil
+		})
+	}
+	return func(name string) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return string(values[name])
+	}
+}
+
+// decodePlayerList 解析 add/remove 的玩家列表参数
+func decodePlayerList(params json.RawMessage) ([]subdto.PlayerDto, *dto.MsmpResponseError) {
+	var args [][]subdto.PlayerDto
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return nil, &dto.MsmpResponseError{Code: -32602, Message: "invalid params"}
+	}
+	return args[0], nil
+}
+
+// statefulAllowlist 注册可增删的白名单，返回读取当前白名单玩家名称的函数
+func statefulAllowlist(f *fakeServer, initial ...subdto.PlayerDto) func() []string {
+	var mutex sync.Mutex
+	players := append([]subdto.PlayerDto(nil), initial...)
+	f.Handle("minecraft:allowlist", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]subdto.PlayerDto{}, players...), nil
+	})
+	f.Handle("minecraft:allowlist/add", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		args, rpcErr := decodePlayerList(params)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		// 不依赖 add 覆盖已有记录，重复添加视为错误
+		for _, a := range args {
+			for _, p := range players {
+				if p.Id == a.Id {
+					return nil, &dto.MsmpResponseError{Code: -32602, Message: "already allowlisted: " + a.Name}
+				}
+			}
+		}
+		players = append(players, args...)
+		return append([]subdto.PlayerDto{}, players...), nil
+	})
+	f.Handle("minecraft:allowlist/remove", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		args, rpcErr := decodePlayerList(params)
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		removed := make(map[string]bool, len(args))
+		for _, a := range args {
+			removed[a.Id] = true
+		}
+		kept := players[:0]
+		for _, p := range players {
+			if !removed[p.Id] {
+				kept = append(kept, p)
+			}
+		}
+		players = kept
+		return append([]subdto.PlayerDto{}, players...), nil
+	})
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		names := make([]string, 0, len(players))
+		for _, p := range players {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	f := newFakeServer(t)
+	setting := statefulSettings(f, map[string]interface{}{
+		mcmsmpgo.SettingUseAllowlist:     true,
+		mcmsmpgo.SettingEnforceAllowlist: false,
+		mcmsmpgo.SettingMotd:             "A Minecraft Server",
+	})
+	staff := subdto.OfflinePlayer("Notch")
+	guest := subdto.OfflinePlayer("guest")
+	f.Result("minecraft:players", []subdto.PlayerDto{staff, guest})
+	f.Handle("minecraft:players/kick", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		var args [][]subdto.KickPlayerDto
+		_ = json.Unmarshal(params, &args)
+		kicked := []subdto.PlayerDto{}
+		for _, k := range args[0] {
+			kicked = append(kicked, k.Player)
+		}
+		return kicked, nil
+	})
+	f.Result("minecraft:server/system_message", true)
+	allowlist := statefulAllowlist(f, subdto.OfflinePlayer("Friend"))
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	state, err := cli.EnterMaintenance(ctx, mcmsmpgo.MaintenanceOptions{
+		Motd:        "Down for maintenance",
+		Warning:     text.Text("Maintenance starts now").WithColor(text.Red).Message(),
+		KickMessage: text.Text("Back soon").Message(),
+		Exempt:      []subdto.PlayerDto{{Name: "notch"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Kicked) != 1 || state.Kicked[0] != guest {
+		t.Fatalf("kicked = %+v", state.Kicked)
+	}
+	if setting(mcmsmpgo.SettingEnforceAllowlist) != "true" || setting(mcmsmpgo.SettingMotd) != `"Down for maintenance"` {
+		t.Fatalf("settings not applied: enforce=%s motd=%s", setting(mcmsmpgo.SettingEnforceAllowlist), setting(mcmsmpgo.SettingMotd))
+	}
+	if n := len(f.CallsOf("minecraft:server/system_message")); n != 1 {
+		t.Fatalf("system_message calls = %d", n)
+	}
+	// 只有名称的豁免玩家按在线玩家的UUID加入白名单
+	if len(state.Allowlisted) != 1 || state.Allowlisted[0] != staff {
+		t.Fatalf("allowlisted = %+v", state.Allowlisted)
+	}
+	// 维护期间以其他方式加入白名单的玩家
+	if _, err := cli.ReconcileAllowlist(ctx, []subdto.PlayerDto{subdto.OfflinePlayer("Friend"), staff, subdto.OfflinePlayer("Helper")}, mcmsmpgo.ReconcileOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.EnterMaintenance(ctx, mcmsmpgo.MaintenanceOptions{}); err != mcmsmpgo.ErrAlreadyInMaintenance {
+		t.Fatalf("second enter err = %v", err)
+	}
+
+	if err := cli.ExitMaintenance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if setting(mcmsmpgo.SettingUseAllowlist) != "true" || setting(mcmsmpgo.SettingEnforceAllowlist) != "false" ||
+		setting(mcmsmpgo.SettingMotd) != `"A Minecraft Server"` {
+		t.Fatalf("settings not restored: use=%s enforce=%s motd=%s", setting(mcmsmpgo.SettingUseAllowlist),
+			setting(mcmsmpgo.SettingEnforceAllowlist), setting(mcmsmpgo.SettingMotd))
+	}
+	if cli.Maintenance() != nil {
+		t.Fatal("maintenance state not cleared")
+	}
+	if got := strings.Join(allowlist(), ","); got != "Friend,Helper" {
+		t.Fatalf("allowlist after exit = %s", got)
+	}
+	if err := cli.ExitMaintenance(ctx); err != mcmsmpgo.ErrNotInMaintenance {
+		t.Fatalf("second exit err = %v", err)
+	}
+}
+
+func TestMaintenanceGraceDoesNotHoldLock(t *testing.T) {
+	f := newFakeServer(t)
+	statefulSettings(f, map[string]interface{}{
+		mcmsmpgo.SettingUseAllowlist:     false,
+		mcmsmpgo.SettingEnforceAllowlist: false,
+	})
+	f.Result("minecraft:players", []subdto.PlayerDto{})
+	f.Result("minecraft:server/system_message", true)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	graceCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		_, err := cli.EnterMaintenance(graceCtx, mcmsmpgo.MaintenanceOptions{
+			Warning: text.Text("Maintenance in one minute").Message(),
+			Grace:   time.Minute,
+		})
+		done <- err
+	}()
+	eventually(t, func() bool {
+		return len(f.CallsOf("minecraft:server/system_message")) == 1
+	})
+
+	// 宽限期内可以查询状态，再次进入会立即失败
+	if cli.Maintenance() != nil {
+		t.Fatal("maintenance state set during grace period")
+	}
+	if _, err := cli.EnterMaintenance(ctx, mcmsmpgo.MaintenanceOptions{}); err != mcmsmpgo.ErrAlreadyInMaintenance {
+		t.Fatalf("enter during grace err = %v", err)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled enter err = %v", err)
+	}
+	if _, err := cli.EnterMaintenance(ctx, mcmsmpgo.MaintenanceOptions{}); err != nil {
+		t.Fatalf("enter after canceled grace err = %v", err)
+	}
+}
+
+func TestMaintenanceRollbackAfterCancel(t *testing.T) {
+	f := newFakeServer(t)
+	setting := statefulSettings(f, map[string]interface{}{
+		mcmsmpgo.SettingUseAllowlist:     false,
+		mcmsmpgo.SettingEnforceAllowlist: false,
+		mcmsmpgo.SettingMotd:             "A Minecraft Server",
+	})
+	f.Result("minecraft:players", []subdto.PlayerDto{})
+	allowlist := statefulAllowlist(f)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	// 修改motd时调用方取消，之前修改的设置仍要恢复
+	enterCtx, cancel := context.WithCancel(ctx)
+	f.Handle("minecraft:serversettings/motd/set", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		cancel()
+		return nil, &dto.MsmpResponseError{Code: -32603, Message: "motd is locked"}
+	})
+	_, err := cli.EnterMaintenance(enterCtx, mcmsmpgo.MaintenanceOptions{
+		Motd:   "Down for maintenance",
+		Exempt: []subdto.PlayerDto{subdto.OfflinePlayer("Notch")},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if setting(mcmsmpgo.SettingUseAllowlist) != "false" || setting(mcmsmpgo.SettingEnforceAllowlist) != "false" {
+		t.Fatalf("settings not rolled back: use=%s enforce=%s", setting(mcmsmpgo.SettingUseAllowlist), setting(mcmsmpgo.SettingEnforceAllowlist))
+	}
+	if got := allowlist(); len(got) != 0 {
+		t.Fatalf("allowlist after rollback = %v", got)
+	}
+	if cli.Maintenance() != nil {
+		t.Fatal("maintenance state set after failed enter")
+	}
+}
+
+func TestRestoreMaintenanceKeepsCurrentState(t *testing.T) {
+	f := newFakeServer(t)
+	statefulSettings(f, map[string]interface{}{
+		mcmsmpgo.SettingUseAllowlist:     false,
+		mcmsmpgo.SettingEnforceAllowlist: false,
+	})
+	f.Result("minecraft:players", []subdto.PlayerDto{})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	state, err := cli.EnterMaintenance(ctx, mcmsmpgo.MaintenanceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 恢复之前保存的其他状态不影响当前记录的维护模式
+	stale := &mcmsmpgo.MaintenanceState{Settings: map[string]json.RawMessage{}}
+	if err := cli.RestoreMaintenance(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	if cli.Maintenance() != state {
+		t.Fatal("restoring another state cleared the current maintenance state")
+	}
+	if err := cli.RestoreMaintenance(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	if cli.Maintenance() != nil {
+		t.Fatal("maintenance state not cleared")
+	}
+}
+
+func TestMaintenanceGraceClock(t *testing.T) {
+	f := newFakeServer(t)
+	setting := statefulSettings(f, map[string]interface{}{
+		mcmsmpgo.SettingUseAllowlist:     false,
+		mcmsmpgo.SettingEnforceAllowlist: false,
+	})
+	f.Result("minecraft:players", []subdto.PlayerDto{})
+	f.Result("minecraft:server/system_message", true)
+	clk := newFakeClock(time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC))
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{Clock: clk})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cli.EnterMaintenance(testContext(t), mcmsmpgo.MaintenanceOptions{
+			Warning: text.Text("Maintenance in ten minutes").Message(),
+			Grace:   10 * time.Minute,
+		})
+		done <- err
+	}()
+	clk.WaitForTimer(t)
+	if setting(mcmsmpgo.SettingEnforceAllowlist) != "false" {
+		t.Fatal("settings changed during grace period")
+	}
+	clk.Advance(10 * time.Minute)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if setting(mcmsmpgo.SettingEnforceAllowlist) != "true" {
+		t.Fatal("settings not applied after grace period")
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/text"
+	"testing"
+)
+
+func TestKickWhere(t *testing.T) {
+	f := newFakeServer(t)
+	staff := subdto.OfflinePlayer("Notch")
+	guest1 := subdto.OfflinePlayer("guest1")
+	guest2 := subdto.OfflinePlayer("guest2")
+	f.Result("minecraft:players", []subdto.PlayerDto{staff, guest1, guest2})
+	f.Handle("minecraft:players/kick", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		var args [][]subdto.KickPlayerDto
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &dto.MsmpResponseError{Code: -32602, Message: err.Error()}
+		}
+		kicked := []subdto.PlayerDto{}
+		for _, k := range args[0] {
+			kicked = append(kicked, k.Player)
+		}
+		return kicked, nil
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	message := text.Text("Maintenance").WithColor(text.Red).Message()
+	kicked, err := cli.KickWhere(ctx, func(p subdto.PlayerDto) bool {
+		return p.Name != "Notch"
+	}, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kicked) != 2 || kicked[0] != guest1 || kicked[1] != guest2 {
+		t.Fatalf("kicked = %+v", kicked)
+	}
+	calls := f.CallsOf("minecraft:players/kick")
+	if len(calls) != 1 {
+		t.Fatalf("kick calls = %d", len(calls))
+	}
+	want := `[[{"player":{"id":"` + guest1.Id + `","name":"guest1"},"message":{"literal":"§cMaintenance"}},` +
+		`{"player":{"id":"` + guest2.Id + `","name":"guest2"},"message":{"literal":"§cMaintenance"}}]]`
+	if got := string(calls[0].Params.(json.RawMessage)); got != want {
+		t.Fatalf("kick params = %s", got)
+	}
+
+	// 没有匹配的玩家时不发送请求
+	kicked, err = cli.KickWhere(ctx, func(subdto.PlayerDto) bool { return false }, subdto.Message{})
+	if err != nil || len(kicked) != 0 {
+		t.Fatalf("kicked = %+v, err = %v", kicked, err)
+	}
+	if n := len(f.CallsOf("minecraft:players/kick")); n != 1 {
+		t.Fatalf("kick calls = %d", n)
+	}
+
+	// 存在无效Id时整批不发送
+	if _, err := cli.KickPlayers(ctx, message, guest1, subdto.PlayerDto{Id: "8484"}); err == nil {
+		t.Fatal("expected error for invalid uuid")
+	}
+	if n := len(f.CallsOf("minecraft:players/kick")); n != 1 {
+		t.Fatalf("kick calls = %d", n)
+	}
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"bytes"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/playtime"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaytimeStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playtime.jsonl")
+	store, err := playtime.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steve := subdto.OfflinePlayer("Steve")
+	alex := subdto.OfflinePlayer("Alex")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+	for _, s := range []mcmsmpgo.Session{
+		{Player: steve, Start: at(2, 20, 0), End: at(2, 21, 0)},
+		// 跨越零点的会话
+		{Player: steve, Start: at(2, 23, 0), End: at(3, 1, 0)},
+		{Player: alex, Start: at(9, 10, 0), End: at(9, 10, 30)},
+	} {
+		if err := store.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Add(mcmsmpgo.Session{Player: steve, Start: at(4, 0, 0)}); err == nil {
+		t.Fatal("expected error for open session")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟崩溃时写了一半的行
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	_, _ = f.WriteString(`{"uuid":"`)
+	_ = f.Close()
+
+	store, err = playtime.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	check := func(stage string) {
+		t.Helper()
+		if total := store.Total(steve); total != 3*time.Hour {
+			t.Fatalf("%s: total = %v", stage, total)
+		}
+		daily := store.Daily(steve, at(2, 12, 0), at(4, 0, 0))
+		if len(daily) != 2 || !daily[0].Start.Equal(at(2, 0, 0)) || daily[0].Duration != 2*time.Hour || daily[1].Duration != time.Hour {
+			t.Fatalf("%s: daily = %+v", stage, daily)
+		}
+		// 2025-06-02是周一
+		weekly := store.Weekly(steve, at(3, 0, 0), at(10, 0, 0))
+		if len(weekly) != 2 || !weekly[0].Start.Equal(at(2, 0, 0)) || weekly[0].Duration != 3*time.Hour || weekly[1].Duration != 0 {
+			t.Fatalf("%s: weekly = %+v", stage, weekly)
+		}
+		if first, ok := store.FirstSeen(steve); !ok || !first.Equal(at(2, 20, 0)) {
+			t.Fatalf("%s: first seen = %v", stage, first)
+		}
+		if last, ok := store.LastSeen(steve); !ok || !last.Equal(at(3, 1, 0)) {
+			t.Fatalf("%s: last seen = %v", stage, last)
+		}
+	}
+	check("reopened")
+
+	// 重写文件时保留原文件的权限
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Compact(at(8, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	check("compacted")
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("unexpected mode %v, %v", info.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("unexpected files %v", entries)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Fatalf("compacted file has %d lines:\n%s", lines, data)
+	}
+
+	// 压缩后仍可以继续追加
+	if err := store.Add(mcmsmpgo.Session{Player: alex, Start: at(10, 10, 0), End: at(10, 11, 0)}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := store.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "uuid,name,total_seconds,sessions,first_seen,last_seen\n" +
+		steve.Id + ",Steve,10800,2,2025-06-02T20:00:00Z,2025-06-03T01:00:00Z\n" +
+		alex.Id + ",Alex,5400,2,2025-06-09T10:00:00Z,2025-06-10T11:00:00Z\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %s", buf.String())
+	}
+}
+
+func TestPlaytimeReopenAfterTruncatedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playtime.jsonl")
+	steve := subdto.OfflinePlayer("Steve")
+	at := func(hour int) time.Time {
+		return time.Date(2025, 6, 2, hour, 0, 0, 0, time.UTC)
+	}
+	add := func(s mcmsmpgo.Session) {
+		t.Helper()
+		store, err := playtime.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Add(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendRaw := func(raw string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.WriteString(raw)
+		_ = f.Close()
+	}
+
+	add(mcmsmpgo.Session{Player: steve, Start: at(1), End: at(2)})
+	// 崩溃时写了一半的行，重新打开后追加的记录不能拼接在它后面
+	appendRaw(`{"uuid":"` + steve.Id + `","name":"Ste`)
+	add(mcmsmpgo.Session{Player: steve, Start: at(3), End: at(4)})
+	// 手动编辑导致最后一条有效记录缺少换行符
+	appendRaw(`{"uuid":"` + steve.Id + `","name":"Steve","start":"2025-06-02T05:00:00Z","end":"2025-06-02T06:00:00Z","seconds":3600}`)
+	add(mcmsmpgo.Session{Player: steve, Start: at(7), End: at(8)})
+
+	store, err := playtime.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if total := store.Total(steve); total != 4*time.Hour {
+		t.Fatalf("total = %v", total)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 4 {
+		t.Fatalf("file has %d lines:\n%s", len(lines), data)
+	}
+}
+
+func TestPlaytimeAttach(t *testing.T) {
+	f := newFakeServer(t)
+	steve := subdto.OfflinePlayer("Steve")
+	alex := subdto.OfflinePlayer("Alex")
+	fakePlayers(f, steve, alex)
+	cli := newTestClient(t, f, nil)
+	clock := newFakeClock(time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC))
+	roster := mcmsmpgo.NewRoster(cli, &mcmsmpgo.RosterConfig{ReconcileInterval: time.Hour, Clock: clock})
+
+	store, err := playtime.Open(filepath.Join(t.TempDir(), "playtime.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	detach := store.Attach(roster)
+	if err := roster.Start(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	defer roster.Stop()
+
+	clock.Advance(10 * time.Minute)
+	f.Notify(mcmsmpgo.NotificationPlayersLeft, alex)
+	eventually(t, func() bool { return store.Total(alex) == 10*time.Minute })
+
+	// 仍在线的会话按在线列表的时钟结束，重复 detach 不会重复记录
+	clock.Advance(20 * time.Minute)
+	if err := detach(); err != nil {
+		t.Fatal(err)
+	}
+	if err := detach(); err != nil {
+		t.Fatal(err)
+	}
+	if total := store.Total(steve); total != 30*time.Minute {
+		t.Fatalf("Steve total = %v", total)
+	}
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statefulBans 注册可增删的封禁玩家列表，重复添加同一玩家返回错误
+func statefulBans(f *fakeServer, initial []subdto.UserBanDto) {
+	var mutex sync.Mutex
+	bans := append([]subdto.UserBanDto{}, initial...)
+	f.Handle("minecraft:bans", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]subdto.UserBanDto{}, bans...), nil
+	})
+	f.Handle("minecraft:bans/add", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		var args [][]subdto.UserBanDto
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+			return nil, &dto.MsmpResponseError{Code: -32602, Message: "invalid params"}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, a := range args[0] {
+			for _, b := range bans {
+				if b.Player.Id == a.Player.Id {
+					return nil, &dto.MsmpResponseError{Code: -32602, Message: "already banned: " + a.Player.Name}
+				}
+			}
+		}
+		bans = append(bans, args[0]...)
+		return append([]subdto.UserBanDto{}, bans...), nil
+	})
+	f.Handle("minecraft:bans/remove", func(params json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		var args [][]subdto.PlayerDto
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+			return nil, &dto.MsmpResponseError{Code: -32602, Message: "invalid params"}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		removed := make(map[string]bool, len(args[0]))
+		for _, p := range args[0] {
+			removed[p.Id] = true
+		}
+		kept := bans[:0]
+		for _, b := range bans {
+			if !removed[b.Player.Id] {
+				kept = append(kept, b)
+			}
+		}
+		bans = kept
+		return append([]subdto.UserBanDto{}, bans...), nil
+	})
+}
+
+func TestReconcileBans(t *testing.T) {
+	jeb := subdto.PlayerDto{Id: "853c80ef-3c37-49fd-aa49-938b674adae6", Name: "jeb_"}
+	notch := subdto.OfflinePlayer("Notch")
+	steve := subdto.OfflinePlayer("Steve")
+	alex := subdto.OfflinePlayer("Alex")
+	expires := subdto.NewBanExpiry(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	f := newFakeServer(t)
+	statefulBans(f, []subdto.UserBanDto{
+		{Player: jeb, Reason: "Griefing", Source: "Server"},
+		{Player: notch, Reason: "Spam", Source: "Server"},
+		{Player: alex, Reason: "Xray", Source: "Server"},
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	desired := []subdto.UserBanDto{
+		// 大写不带横线的UUID视为同一玩家，来源为空不参与比较
+		{Player: subdto.PlayerDto{Id: "853C80EF3C3749FDAA49938B674ADAE6", Name: "jeb_"}, Reason: "Griefing"},
+		{Player: steve, Reason: "Xray", Expires: expires},
+		{Player: notch, Reason: "Spam, again"},
+	}
+
+	report, err := cli.ReconcileBans(ctx, desired, mcmsmpgo.ReconcileOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || len(report.Added) != 1 || len(report.Changed) != 1 || len(report.Removed) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(f.CallsOf("minecraft:bans/add"))+len(f.CallsOf("minecraft:bans/remove")) != 0 {
+		t.Fatal("dry run must not modify the server")
+	}
+
+	// 新增、修改和移除合并为一次 remove 和一次 add，修改的封禁先移除再添加
+	report, err = cli.ReconcileBans(ctx, desired, mcmsmpgo.ReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Added) != 1 || report.Added[0].Player != steve || len(report.Removed) != 1 || report.Removed[0].Player != alex ||
+		len(report.Changed) != 1 || report.Changed[0].After.Reason != "Spam, again" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if n := len(f.CallsOf("minecraft:bans/add")); n != 1 {
+		t.Fatalf("bans/add called %d times", n)
+	}
+	if n := len(f.CallsOf("minecraft:bans/remove")); n != 1 {
+		t.Fatalf("bans/remove called %d times", n)
+	}
+
+	// 再次收敛时服务端已与期望一致
+	report, err = cli.ReconcileBans(ctx, desired, mcmsmpgo.ReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Empty() {
+		t.Fatalf("expected no changes, got %+v", report)
+	}
+	if n := len(f.CallsOf("minecraft:bans/add")); n != 1 {
+		t.Fatalf("bans/add called %d times", n)
+	}
+}
+
+func TestDiffIpBansCanonicalKey(t *testing.T) {
+	current := []subdto.IpBanDTO{{Ip: "0:0:0:0:0:0:0:1", Reason: "Spam"}, {Ip: "10.0.0.1"}}
+	desired := []subdto.IpBanDTO{{Ip: "::1", Reason: "Spam"}, {Ip: "10.0.0.1"}}
+	if report, err := mcmsmpgo.DiffIpBans(current, desired); err != nil || !report.Empty() {
+		t.Fatalf("expected no changes, got %+v, %v", report, err)
+	}
+	if key := mcmsmpgo.IpKey("0:0:0:0:0:0:0:1"); key != "::1" {
+		t.Fatalf("unexpected key %q", key)
+	}
+}
+
+func TestDiffOperatorsIdempotent(t *testing.T) {
+	ops := []subdto.OperatorDto{
+		{PermissionLevel: 4, Player: subdto.OfflinePlayer("Notch")},
+		{PermissionLevel: 2, Player: subdto.OfflinePlayer("Steve")},
+	}
+	if report, err := mcmsmpgo.DiffOperators(ops, ops); err != nil || !report.Empty() {
+		t.Fatalf("expected no changes, got %+v, %v", report, err)
+	}
+	changed := append([]subdto.OperatorDto(nil), ops...)
+	changed[1].PermissionLevel = 3
+	report, err := mcmsmpgo.DiffOperators(ops, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Before.PermissionLevel != 2 || report.Changed[0].After.PermissionLevel != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestDiffDuplicateKeys(t *testing.T) {
+	// 同一玩家以不同形式的Id出现两次，无法确定期望的权限等级
+	desired := []subdto.OperatorDto{
+		{PermissionLevel: 4, Player: subdto.PlayerDto{Id: "853c80ef-3c37-49fd-aa49-938b674adae6", Name: "jeb_"}},
+		{PermissionLevel: 2, Player: subdto.PlayerDto{Id: "853C80EF3C3749FDAA49938B674ADAE6", Name: "jeb_"}},
+	}
+	if _, err := mcmsmpgo.DiffOperators(nil, desired); !errors.Is(err, mcmsmpgo.ErrDuplicateKey) {
+		t.Fatalf("expected ErrDuplicateKey, got %v", err)
+	}
+
+	f := newFakeServer(t)
+	f.Result("minecraft:ip_bans", []subdto.IpBanDTO{})
+	cli := newTestClient(t, f, nil)
+	_, err := cli.ReconcileIpBans(testContext(t), []subdto.IpBanDTO{{Ip: "10.0.0.1"}, {Ip: "10.0.0.1", Reason: "again"}}, mcmsmpgo.ReconcileOptions{})
+	if !errors.Is(err, mcmsmpgo.ErrDuplicateKey) {
+		t.Fatalf("expected ErrDuplicateKey, got %v", err)
+	}
+	if n := len(f.CallsOf("minecraft:ip_bans/add")); n != 0 {
+		t.Fatalf("ip_bans/add called %d times", n)
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"encoding/json"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePlayers 注册 minecraft:players，返回修改在线玩家的函数
+func fakePlayers(f *fakeServer, initial ...subdto.PlayerDto) func(players ...subdto.PlayerDto) {
+	var mutex sync.Mutex
+	online := initial
+	f.Handle("minecraft:players", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]subdto.PlayerDto{}, online...), nil
+	})
+	return func(players ...subdto.PlayerDto) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		online = players
+	}
+}
+
+func TestRoster(t *testing.T) {
+	f := newFakeServer(t)
+	steve := subdto.OfflinePlayer("Steve")
+	alex := subdto.OfflinePlayer("Alex")
+	setOnline := fakePlayers(f, steve)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+	clock := newFakeClock(time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC))
+
+	roster := mcmsmpgo.NewRoster(cli, &mcmsmpgo.RosterConfig{ReconcileInterval: time.Hour, Clock: clock})
+	var mutex sync.Mutex
+	var events []mcmsmpgo.RosterEvent
+	roster.AddListener(func(e mcmsmpgo.RosterEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, e)
+	})
+	if err := roster.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer roster.Stop()
+	if !roster.IsOnline(steve) {
+		t.Fatal("initial reconcile did not find Steve")
+	}
+
+	clock.Advance(10 * time.Minute)
+	f.Notify(mcmsmpgo.NotificationPlayersJoined, alex)
+	eventually(t, func() bool { return roster.Count() == 2 })
+	clock.Advance(30 * time.Minute)
+	f.Notify(mcmsmpgo.NotificationPlayersLeft, subdto.PlayerDto{Id: alex.Id})
+	eventually(t, func() bool { return roster.Count() == 1 })
+
+	sessions := roster.Sessions(alex)
+	if len(sessions) != 1 || sessions[0].Duration(clock.Now()) != 30*time.Minute || sessions[0].Player.Name != "Alex" {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+	if d, ok := roster.SessionLength(steve); !ok || d != 40*time.Minute {
+		t.Fatalf("Steve session = %v %v", d, ok)
+	}
+	if peak, at := roster.Peak(); peak != 2 || !at.Equal(time.Date(2025, 6, 1, 20, 10, 0, 0, time.UTC)) {
+		t.Fatalf("peak = %d at %v", peak, at)
+	}
+
+	// 丢失了Steve的下线通知，对账时结束他的会话
+	setOnline()
+	clock.Advance(5 * time.Minute)
+	if err := roster.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if roster.Count() != 0 || roster.Sessions(steve)[0].Duration(clock.Now()) != 45*time.Minute {
+		t.Fatalf("online = %+v", roster.Online())
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := []struct {
+		typ        mcmsmpgo.RosterEventType
+		name       string
+		reconciled bool
+	}{
+		{mcmsmpgo.SessionStarted, "Steve", true},
+		{mcmsmpgo.SessionStarted, "Alex", false},
+		{mcmsmpgo.SessionEnded, "Alex", false},
+		{mcmsmpgo.SessionEnded, "Steve", true},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Type != w.typ || e.Session.Player.Name != w.name || e.Reconciled != w.reconciled {
+			t.Fatalf("event %d = %+v", i, e)
+		}
+	}
+}
+
+func TestRosterReconcileDuringNotification(t *testing.T) {
+	f := newFakeServer(t)
+	steve := subdto.OfflinePlayer("Steve")
+	alex := subdto.OfflinePlayer("Alex")
+	fakePlayers(f, alex)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	roster := mcmsmpgo.NewRoster(cli, &mcmsmpgo.RosterConfig{ReconcileInterval: time.Hour})
+	if err := roster.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer roster.Stop()
+
+	// 服务端在生成快照之后、返回响应之前推送了通知：Steve 上线，Alex 下线
+	f.Handle("minecraft:players", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		f.Notify(mcmsmpgo.NotificationPlayersJoined, steve)
+		f.Notify(mcmsmpgo.NotificationPlayersLeft, alex)
+		return []subdto.PlayerDto{alex}, nil
+	})
+	if err := roster.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !roster.IsOnline(steve) || roster.IsOnline(alex) {
+		t.Fatalf("stale snapshot applied: online = %+v", roster.Online())
+	}
+
+	// 之后的快照不再受旧通知影响
+	fakePlayers(f, alex)
+	if err := roster.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if roster.IsOnline(steve) || !roster.IsOnline(alex) {
+		t.Fatalf("later snapshot ignored: online = %+v", roster.Online())
+	}
+}
+
+func TestRosterConnectionLost(t *testing.T) {
+	f := newFakeServer(t)
+	steve := subdto.OfflinePlayer("Steve")
+	fakePlayers(f, steve)
+	cli := newTestClient(t, f, nil)
+	clock := newFakeClock(time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC))
+
+	roster := mcmsmpgo.NewRoster(cli, &mcmsmpgo.RosterConfig{ReconcileInterval: time.Hour, Clock: clock})
+	ended := make(chan mcmsmpgo.RosterEvent, 1)
+	roster.AddListener(func(e mcmsmpgo.RosterEvent) {
+		if e.Type == mcmsmpgo.SessionEnded {
+			ended <- e
+		}
+	})
+	if err := roster.Start(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	defer roster.Stop()
+
+	// 连接断开时立即以断开的时间结束会话，不等到重新连接后的对账
+	clock.Advance(25 * time.Minute)
+	f.Drop()
+	e := <-ended
+	if !e.Disconnected || e.Reconciled || e.Session.Player != steve || !e.Session.End.Equal(time.Date(2025, 6, 1, 20, 25, 0, 0, time.UTC)) {
+		t.Fatalf("event = %+v", e)
+	}
+	if roster.Count() != 0 {
+		t.Fatalf("online = %+v", roster.Online())
+	}
+	if sessions := roster.Sessions(steve); len(sessions) != 1 || sessions[0].Duration(clock.Now()) != 25*time.Minute {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/handler"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	r := handler.NewRouter()
+	var got []string
+	record := func(name string) handler.HandlerFunc {
+		return func(*dto.MsmpRequest, dto.MsmpResponse) {
+			got = append(got, name)
+		}
+	}
+	r.Handle("minecraft:allowlist/*", record("allowlist"))
+	r.Handle("minecraft:allowlist/clear", record("clear"))
+	r.Handle("minecraft:*", record("minecraft"))
+	r.Fallback(record("fallback"))
+
+	var players []subdto.PlayerDto
+	handler.HandleTyped(r, "minecraft:players", func(_ *dto.MsmpRequest, result []subdto.PlayerDto, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		players = result
+		got = append(got, "players")
+	})
+
+	for _, method := range []string{
+		"minecraft:allowlist",
+		"minecraft:allowlist/add",
+		"minecraft:allowlist/clear",
+		"minecraft:allowlisted",
+		"mymod:foo",
+	} {
+		request := dto.NewMsmpRequest(1, method, nil)
+		r.Dispatch(&request, &dto.MsmpResponseSuccess{JSONRPC: "2.0", ID: 1})
+	}
+	response, err := dto.ParseResponse([]byte(`{"jsonrpc":"2.0","id":2,"result":[{"id":"a","name":"jeb_"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := dto.NewMsmpRequest(2, "minecraft:players", nil)
+	r.Dispatch(&request, response)
+
+	want := []string{"allowlist", "allowlist", "clear", "minecraft", "fallback", "players"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if len(players) != 1 || players[0].Name != "jeb_" {
+		t.Fatalf("unexpected players %+v", players)
+	}
+}
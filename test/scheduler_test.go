@@ -0,0 +1,225 @@
+package test
+
+import (
+	"context"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/scheduler"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventually 在超时前反复检查条件
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2025, time.March, 14, 10, 30, 15, 0, time.UTC)
+	for _, tc := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 3, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2025, 3, 15, 4, 0, 0, 0, time.UTC)},
+		{"30 2 * * sun", time.Date(2025, 3, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan-mar *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 8-18/4 * * 1-5", time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)},
+		{"30 */10 * * * *", time.Date(2025, 3, 14, 10, 30, 30, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+	} {
+		s, err := scheduler.ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(tc.want) {
+			t.Errorf("%s: next = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+	for _, expr := range []string{"", "* * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every -1s"} {
+		if _, err := scheduler.ParseCron(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 1, 1, 0, 0, 30, 0, time.UTC))
+	s := scheduler.New(&scheduler.Config{Clock: clock})
+	started := make(chan struct{}, 4)
+	release := make(chan struct{})
+	err := s.Add(scheduler.Job{
+		Name:     "save",
+		Schedule: scheduler.MustParseCron("* * * * *"),
+		Run: func(ctx context.Context) error {
+			started <- struct{}{}
+			<-release
+			return errors.New("disk full")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	clock.WaitForTimer(t)
+	clock.Advance(30 * time.Second)
+	<-started
+	// 上一次执行未结束，下一分钟的执行被跳过
+	clock.WaitForTimer(t)
+	clock.Advance(time.Minute)
+	eventually(t, func() bool {
+		status, _ := s.Status("save")
+		return status.Overlapped == 1
+	})
+	close(release)
+	eventually(t, func() bool {
+		status, _ := s.Status("save")
+		return status.Runs == 1 && status.Running == 0
+	})
+	status, _ := s.Status("save")
+	if status.Failures != 1 || status.LastError == nil || !status.Next.Equal(time.Date(2025, 1, 1, 0, 3, 0, 0, time.UTC)) {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := scheduler.New(&scheduler.Config{Clock: clock, MissedTolerance: time.Minute})
+	var mutex sync.Mutex
+	runs := map[string]int{}
+	for _, job := range []scheduler.Job{
+		{Name: "catch-up", MissedRuns: scheduler.RunOnce},
+		{Name: "skip", MissedRuns: scheduler.SkipMissed},
+	} {
+		name := job.Name
+		job.Schedule = scheduler.MustParseCron("*/5 * * * *")
+		job.Run = func(context.Context) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			runs[name]++
+			return nil
+		}
+		if err := s.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	// 模拟进程挂起了半小时
+	clock.WaitForTimer(t)
+	clock.Advance(30 * time.Minute)
+	eventually(t, func() bool {
+		status, _ := s.Status("skip")
+		return status.Missed == 1
+	})
+	eventually(t, func() bool {
+		status, _ := s.Status("catch-up")
+		return status.Runs == 1
+	})
+	mutex.Lock()
+	defer mutex.Unlock()
+	if runs["catch-up"] != 1 || runs["skip"] != 0 {
+		t.Fatalf("runs = %v", runs)
+	}
+	for _, status := range s.Statuses() {
+		if !status.Next.Equal(time.Date(2025, 1, 1, 0, 35, 0, 0, time.UTC)) {
+			t.Fatalf("%s next = %v", status.Name, status.Next)
+		}
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := scheduler.New(&scheduler.Config{Clock: clock})
+	for i := 0; i < 20; i++ {
+		name := string(rune('a' + i))
+		err := s.Add(scheduler.Job{
+			Name:     name,
+			Schedule: scheduler.MustParseCron("@hourly"),
+			Jitter:   10 * time.Minute,
+			Run:      func(context.Context) error { return nil },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, _ := s.Status(name)
+		hour := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+		if status.Next.Before(hour) || !status.Next.Before(hour.Add(10*time.Minute)) {
+			t.Fatalf("next = %v", status.Next)
+		}
+	}
+	if err := s.Add(scheduler.Job{Name: "a", Schedule: scheduler.Every(time.Hour), Run: func(context.Context) error { return nil }}); !errors.Is(err, scheduler.ErrDuplicateJob) {
+		t.Fatalf("duplicate err = %v", err)
+	}
+}
+
+func TestSchedulerRestartJob(t *testing.T) {
+	f := fakeStoppableServer(t)
+	// 客户端使用系统时钟，倒计时只能由调度器的时钟推进
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Hour})
+	clock := newFakeClock(time.Date(2025, 1, 1, 3, 59, 0, 0, time.UTC))
+	s := scheduler.New(&scheduler.Config{Clock: clock})
+	err := s.Add(scheduler.Job{
+		Name:     "nightly-restart",
+		Schedule: scheduler.MustParseCron("0 4 * * *"),
+		Run: scheduler.Restart(cli, 5*time.Minute, mcmsmpgo.StopOptions{
+			Checkpoints: []time.Duration{time.Minute},
+			Countdown: func(remaining time.Duration) subdto.Message {
+				return subdto.Message{Literal: remaining.String()}
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	clock.WaitForTimer(t)
+	clock.Advance(time.Minute)
+	// 调度器等待下一次执行的定时器和倒计时的定时器
+	for i, step := range []time.Duration{4 * time.Minute, time.Minute} {
+		clock.WaitForTimers(t, 2)
+		if n := len(systemMessages(t, f)); n != i+1 {
+			t.Fatalf("%d announcements before step %d", n, i)
+		}
+		clock.Advance(step)
+	}
+	eventually(t, func() bool {
+		status, _ := s.Status("nightly-restart")
+		return status.Runs == 1
+	})
+	status, _ := s.Status("nightly-restart")
+	if status.LastError != nil {
+		t.Fatal(status.LastError)
+	}
+	if got := strings.Join(systemMessages(t, f), ","); got != "5m0s,1m0s" {
+		t.Fatalf("countdown = %s", got)
+	}
+	if n := len(f.CallsOf("minecraft:server/stop")); n != 1 {
+		t.Fatalf("server/stop called %d times", n)
+	}
+}
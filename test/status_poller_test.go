@@ -0,0 +1,165 @@
+package test
+
+import (
+	"encoding/json"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStatusPoller(t *testing.T) {
+	f := newFakeServer(t)
+	var down atomic.Bool
+	var stateMutex sync.Mutex
+	state := subdto.ServerState{Started: true, Version: subdto.Version{Name: "1.21.9", Protocol: 773}}
+	f.Handle("minecraft:server/status", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		if down.Load() {
+			return nil, &dto.MsmpResponseError{Code: -32603, Message: "Internal error"}
+		}
+		stateMutex.Lock()
+		defer stateMutex.Unlock()
+		return state, nil
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+	clock := newFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	// 轮询间隔长于测试推进的时间，只由测试触发轮询
+	poller := mcmsmpgo.NewStatusPoller(cli, &mcmsmpgo.StatusPollerConfig{Interval: 24 * time.Hour, Clock: clock})
+	var mutex sync.Mutex
+	var events []mcmsmpgo.StatusEventType
+	poller.AddListener(func(e mcmsmpgo.StatusEvent) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, e.Type)
+	})
+	if err := poller.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer poller.Stop()
+
+	clock.Advance(time.Hour)
+	if up := poller.Snapshot().Uptime(clock.Now()); up != time.Hour {
+		t.Fatalf("uptime = %v", up)
+	}
+
+	// 心跳通知带来新版本
+	upgraded := subdto.ServerState{Started: true, Version: subdto.Version{Name: "1.21.10", Protocol: 773}}
+	f.Notify(mcmsmpgo.NotificationServerStatus, upgraded)
+	eventually(t, func() bool { return poller.Snapshot().FromHeartbeat })
+	if poller.Snapshot().State.Version.Name != "1.21.10" {
+		t.Fatalf("snapshot = %+v", poller.Snapshot())
+	}
+
+	f.Notify(mcmsmpgo.NotificationServerStopping)
+	eventually(t, func() bool { return !poller.Snapshot().State.Started })
+	if up := poller.Snapshot().Uptime(clock.Now()); up != 0 {
+		t.Fatalf("uptime while stopped = %v", up)
+	}
+
+	stateMutex.Lock()
+	state = upgraded
+	stateMutex.Unlock()
+	clock.Advance(time.Minute)
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := poller.Snapshot()
+	if !snapshot.State.Started || snapshot.Restarts != 1 || !snapshot.StartedAt.Equal(clock.Now()) {
+		t.Fatalf("snapshot after restart = %+v", snapshot)
+	}
+	started := snapshot.StartedAt
+
+	down.Store(true)
+	if err := poller.Poll(ctx); err == nil {
+		t.Fatal("expected poll error")
+	}
+	if snapshot := poller.Snapshot(); snapshot.Available || snapshot.State.Version.Name != "1.21.10" {
+		t.Fatalf("snapshot while unavailable = %+v", snapshot)
+	}
+
+	// 轮询失败后恢复，连接一直正常，不算重启
+	down.Store(false)
+	clock.Advance(time.Minute)
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := poller.Snapshot(); !snapshot.Available || snapshot.Restarts != 1 || !snapshot.StartedAt.Equal(started) {
+		t.Fatalf("snapshot after poll error = %+v", snapshot)
+	}
+
+	// 连接断开后重新连接，计为一次重启
+	f.Drop()
+	eventually(t, func() bool { return !poller.Snapshot().Available })
+	if err := cli.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := poller.Snapshot(); !snapshot.Available || snapshot.Restarts != 2 || !snapshot.StartedAt.Equal(clock.Now()) {
+		t.Fatalf("snapshot after reconnect = %+v", snapshot)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := []mcmsmpgo.StatusEventType{
+		mcmsmpgo.StatusStarted,
+		mcmsmpgo.StatusVersionChanged,
+		mcmsmpgo.StatusStopped,
+		mcmsmpgo.StatusStarted,
+		mcmsmpgo.StatusUnavailable,
+		mcmsmpgo.StatusUnavailable,
+		mcmsmpgo.StatusStarted,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v", events)
+		}
+	}
+}
+
+func TestStatusPollerFirstSeenStopped(t *testing.T) {
+	f := newFakeServer(t)
+	var started atomic.Bool
+	f.Handle("minecraft:server/status", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return subdto.ServerState{Started: started.Load()}, nil
+	})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+	clock := newFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	poller := mcmsmpgo.NewStatusPoller(cli, &mcmsmpgo.StatusPollerConfig{Interval: 24 * time.Hour, Clock: clock})
+	if err := poller.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer poller.Stop()
+
+	// 开始跟踪时服务端处于停止状态，之后的启动不算重启
+	started.Store(true)
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := poller.Snapshot(); !snapshot.State.Started || snapshot.Restarts != 0 {
+		t.Fatalf("snapshot after first start = %+v", snapshot)
+	}
+
+	// 观察到运行之后停止再启动，计为一次重启
+	started.Store(false)
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	started.Store(true)
+	if err := poller.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot := poller.Snapshot(); snapshot.Restarts != 1 {
+		t.Fatalf("snapshot after restart = %+v", snapshot)
+	}
+}
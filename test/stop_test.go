@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStoppableServer 注册停服流程涉及的方法，保存与停止时推送对应的通知
+func fakeStoppableServer(t *testing.T) *fakeServer {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/system_message", true)
+	f.Result("minecraft:players", []subdto.PlayerDto{subdto.OfflinePlayer("jeb_")})
+	f.Result("minecraft:players/kick", []subdto.PlayerDto{subdto.OfflinePlayer("jeb_")})
+	f.Handle("minecraft:server/save", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		go func() {
+			f.Notify(mcmsmpgo.NotificationServerSaving)
+			time.Sleep(10 * time.Millisecond)
+			f.Notify(mcmsmpgo.NotificationServerSaved)
+		}()
+		return true, nil
+	})
+	f.Handle("minecraft:server/stop", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		go f.Notify(mcmsmpgo.NotificationServerStopping)
+		return true, nil
+	})
+	return f
+}
+
+func systemMessages(t *testing.T, f *fakeServer) []string {
+	var list []string
+	for _, call := range f.CallsOf("minecraft:server/system_message") {
+		var args []subdto.SystemMessageDto
+		if err := json.Unmarshal(call.Params.(json.RawMessage), &args); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, args[0].Message.Literal)
+	}
+	return list
+}
+
+func TestScheduleStop(t *testing.T) {
+	f := fakeStoppableServer(t)
+	cli := newTestClient(t, f, nil)
+
+	err := cli.ScheduleStop(testContext(t), 60*time.Millisecond, mcmsmpgo.StopOptions{
+		Checkpoints: []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, time.Minute},
+		Countdown: func(remaining time.Duration) subdto.Message {
+			return subdto.Message{Literal: remaining.String()}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(systemMessages(t, f), ","); got != "60ms,40ms,20ms" {
+		t.Fatalf("countdown = %s", got)
+	}
+	var order []string
+	for _, call := range f.Calls() {
+		switch call.Method {
+		case "minecraft:players/kick", "minecraft:server/save", "minecraft:server/stop":
+			order = append(order, call.Method)
+		}
+	}
+	if strings.Join(order, ",") != "minecraft:players/kick,minecraft:server/save,minecraft:server/stop" {
+		t.Fatalf("order = %v", order)
+	}
+}
+
+func TestScheduleStopClock(t *testing.T) {
+	f := fakeStoppableServer(t)
+	clk := newFakeClock(time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC))
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{Clock: clk})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.ScheduleStop(testContext(t), 10*time.Minute, mcmsmpgo.StopOptions{
+			Checkpoints: []time.Duration{5 * time.Minute, time.Minute},
+			Countdown: func(remaining time.Duration) subdto.Message {
+				return subdto.Message{Literal: remaining.String()}
+			},
+		})
+	}()
+	// 倒计时完全由注入的时钟推进，不等待真实时间
+	for i, step := range []time.Duration{5 * time.Minute, 4 * time.Minute, time.Minute} {
+		clk.WaitForTimer(t)
+		if n := len(systemMessages(t, f)); n != i+1 {
+			t.Fatalf("%d announcements before step %d", n, i)
+		}
+		clk.Advance(step)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(systemMessages(t, f), ","); got != "10m0s,5m0s,1m0s" {
+		t.Fatalf("countdown = %s", got)
+	}
+	if n := len(f.CallsOf("minecraft:server/stop")); n != 1 {
+		t.Fatalf("server/stop called %d times", n)
+	}
+}
+
+func TestScheduleStopCancel(t *testing.T) {
+	f := fakeStoppableServer(t)
+	cli := newTestClient(t, f, nil)
+
+	ctx, cancel := context.WithCancel(testContext(t))
+	time.AfterFunc(30*time.Millisecond, cancel)
+	err := cli.ScheduleStop(ctx, time.Minute, mcmsmpgo.StopOptions{
+		CancelMessage: subdto.Message{Literal: "Restart cancelled"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	messages := systemMessages(t, f)
+	if len(messages) != 2 || messages[1] != "Restart cancelled" {
+		t.Fatalf("messages = %q", messages)
+	}
+	if len(f.CallsOf("minecraft:server/stop")) != 0 {
+		t.Fatal("server stopped after cancel")
+	}
+}
+
+func TestStopWaitsForConnectionClose(t *testing.T) {
+	f := fakeStoppableServer(t)
+	// 模拟服务端不发送停止通知，直接断开连接
+	f.Handle("minecraft:server/stop", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		go f.Close()
+		return true, nil
+	})
+	cli := newTestClient(t, f, nil)
+	cli.SetAutoReconnect(false)
+
+	if err := cli.ScheduleStop(testContext(t), 0, mcmsmpgo.StopOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/text"
+	"strings"
+	"testing"
+)
+
+func TestComponent(t *testing.T) {
+	c := text.Text("Server restart in ").WithColor(text.Gold).Append(
+		text.Text("5 minutes").WithColor(text.Red).Bolded(),
+		text.Text("!"),
+		text.Text(" [details]").WithColor(text.Aqua).Underline().
+			OpenURL("https://example.com/status").
+			HoverText(text.Text("Open status page")),
+	)
+
+	if got := c.PlainText(); got != "Server restart in 5 minutes! [details]" {
+		t.Fatalf("plain text = %q", got)
+	}
+	if got := c.Legacy(); got != "§6Server restart in §r§c§l5 minutes§r§6!§r§b§n [details]" {
+		t.Fatalf("legacy = %q", got)
+	}
+	if m := c.Message(); m.Literal != c.Legacy() || m.Translatable != "" {
+		t.Fatalf("message = %+v", m)
+	}
+
+	data, err := json.Marshal(c.Extra[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"text":" [details]","color":"aqua","underlined":true,"click_event":{"action":"open_url","url":"https://example.com/status"},"hover_event":{"action":"show_text","value":{"text":"Open status page"}}}`
+	if string(data) != want {
+		t.Fatalf("json = %s", data)
+	}
+	data, _ = json.Marshal(text.Text("/spawn").RunCommand("/spawn"))
+	if string(data) != `{"text":"/spawn","click_event":{"action":"run_command","command":"/spawn"}}` {
+		t.Fatalf("json = %s", data)
+	}
+
+	// 协议消息无法携带事件，StrictMessage 返回错误而不是丢弃
+	if _, err := c.StrictMessage(); !errors.Is(err, text.ErrEventsNotSupported) {
+		t.Fatalf("expected ErrEventsNotSupported, got %v", err)
+	}
+	if m, err := text.Text("plain").StrictMessage(); err != nil || m.Literal != "plain" {
+		t.Fatalf("strict message = %+v, %v", m, err)
+	}
+
+	translated := text.Translate("multiplayer.player.joined", text.Text("jeb_"))
+	m := translated.Message()
+	if m.Translatable != "multiplayer.player.joined" || len(m.TranslatableParams) != 1 || m.TranslatableParams[0] != "jeb_" {
+		t.Fatalf("translatable message = %+v", m)
+	}
+
+	staff := subdto.OfflinePlayer("Notch")
+	msg := text.Text("Backup done").WithColor(text.Green).SystemMessage(true, staff)
+	data, _ = json.Marshal(msg)
+	if string(data) != `{"message":{"literal":"§aBackup done"},"overlay":true,"receivingPlayers":[{"id":"`+staff.Id+`","name":"Notch"}]}` {
+		t.Fatalf("system message = %s", data)
+	}
+}
+
+func TestSendSystemMessageNormalizesIds(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/system_message", true)
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	message := subdto.SystemMessageDto{Message: text.Text("hi").Message()}
+	message.ReceivingPlayers = []subdto.PlayerDto{{Id: "8484"}}
+	if err := cli.SendSystemMessage(ctx, message); err == nil || len(f.Calls()) != 0 {
+		t.Fatalf("err = %v, calls = %d", err, len(f.Calls()))
+	}
+	message.ReceivingPlayers = []subdto.PlayerDto{{Id: "853C80EF3C3749FDAA49938B674ADAE6"}}
+	if err := cli.SendSystemMessage(ctx, message); err != nil {
+		t.Fatal(err)
+	}
+	calls := f.CallsOf("minecraft:server/system_message")
+	if len(calls) != 1 || !strings.Contains(string(calls[0].Params.(json.RawMessage)), `"853c80ef-3c37-49fd-aa49-938b674adae6"`) {
+		t.Fatalf("calls = %+v", calls)
+	}
+}
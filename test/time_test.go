@@ -0,0 +1,82 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/vanilla"
+	"testing"
+	"time"
+)
+
+func TestBanExpiry(t *testing.T) {
+	var ban subdto.UserBanDto
+	for _, data := range []string{
+		`{"player":{"id":"","name":"jeb_"},"reason":"","source":""}`,
+		`{"expires":null,"player":{"id":"","name":"jeb_"},"reason":"","source":""}`,
+		`{"expires":"forever","player":{"id":"","name":"jeb_"},"reason":"","source":""}`,
+	} {
+		if err := json.Unmarshal([]byte(data), &ban); err != nil {
+			t.Fatal(err)
+		}
+		if !ban.Expires.Permanent() {
+			t.Fatalf("%s: expected permanent ban", data)
+		}
+	}
+	out, err := json.Marshal(ban)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"player":{"id":"","name":"jeb_"},"reason":"","source":""}` {
+		t.Fatalf("permanent ban marshalled as %s", out)
+	}
+
+	ban.Expires = subdto.NewBanExpiry(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	out, _ = json.Marshal(ban)
+	if string(out) != `{"expires":"2025-10-01T12:00:00Z","player":{"id":"","name":"jeb_"},"reason":"","source":""}` {
+		t.Fatalf("temporary ban marshalled as %s", out)
+	}
+	var back subdto.UserBanDto
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.Expires.Equal(ban.Expires.Time) {
+		t.Fatalf("round trip %v != %v", back.Expires, ban.Expires)
+	}
+	if !back.Expires.Expired(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatal("ban should be expired at its expiry time")
+	}
+
+	if e := subdto.ExpiresIn(time.Hour); e.Permanent() || time.Until(e.Time) > time.Hour {
+		t.Fatalf("unexpected expiry %v", e)
+	}
+	if !subdto.ExpiresIn(0).Permanent() {
+		t.Fatal("zero duration should be permanent")
+	}
+}
+
+func TestVanillaTimeSentinel(t *testing.T) {
+	// 只有 expires 使用 "forever"，零值的 created 不能被写成永久
+	out, err := json.Marshal(vanilla.BannedIpEntry{Ip: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"ip":"10.0.0.1","created":"","source":"","expires":"forever","reason":""}` {
+		t.Fatalf("zero entry marshalled as %s", out)
+	}
+	var e vanilla.BannedIpEntry
+	if err := json.Unmarshal([]byte(`{"ip":"10.0.0.1","created":"forever"}`), &e); err == nil {
+		t.Fatal("expected error for created \"forever\"")
+	}
+
+	// 原版文件中的到期时间解析为 subdto.BanExpiry，并按原版格式写回
+	const timed = `{"ip":"10.0.0.1","created":"2024-05-01 12:00:00 +0000","source":"","expires":"2030-01-01 00:00:00 +0000","reason":""}`
+	if err := json.Unmarshal([]byte(timed), &e); err != nil {
+		t.Fatal(err)
+	}
+	if !e.Expires.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) || !e.ToIpBan().Expires.Equal(e.Expires.Time) {
+		t.Fatalf("expires = %v", e.Expires)
+	}
+	if out, _ := json.Marshal(e); string(out) != timed {
+		t.Fatalf("timed entry marshalled as %s", out)
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"testing"
+)
+
+func TestUUID(t *testing.T) {
+	dashed := "853c80ef-3c37-49fd-aa49-938b674adae6"
+	for _, s := range []string{dashed, "853c80ef3c3749fdaa49938b674adae6", "853C80EF-3C37-49FD-AA49-938B674ADAE6"} {
+		u, err := subdto.ParseUUID(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.String() != dashed || u.Undashed() != "853c80ef3c3749fdaa49938b674adae6" || u.Version() != 4 {
+			t.Fatalf("%s parsed as %s", s, u)
+		}
+	}
+	for _, s := range []string{"", "853c80ef-3c37-49fd-aa49-938b674adae", "853c80ef_3c37_49fd_aa49_938b674adae6", "zz3c80ef3c3749fdaa49938b674adae6"} {
+		if _, err := subdto.ParseUUID(s); err == nil {
+			t.Fatalf("expected %q to be rejected", s)
+		}
+	}
+
+	// 与原版 UUID.nameUUIDFromBytes("OfflinePlayer:Notch") 的结果一致
+	offline := subdto.OfflineUUID("Notch")
+	if offline.String() != "b50ad385-829d-3141-a216-7e7d7539ba7f" || offline.Version() != 3 {
+		t.Fatalf("offline uuid = %s", offline)
+	}
+	player := subdto.OfflinePlayer("Notch")
+	if player.Id != offline.String() || player.Name != "Notch" {
+		t.Fatalf("unexpected player %+v", player)
+	}
+
+	normalized, err := subdto.PlayerDto{Id: "853C80EF3C3749FDAA49938B674ADAE6", Name: "jeb_"}.Normalize()
+	if err != nil || normalized.Id != dashed {
+		t.Fatalf("normalize = %+v, %v", normalized, err)
+	}
+
+	var decoded struct {
+		Id subdto.UUID `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(`{"id":"853c80ef3c3749fdaa49938b674adae6"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(decoded)
+	if string(out) != `{"id":"`+dashed+`"}` {
+		t.Fatalf("marshalled as %s", out)
+	}
+}
+
+func TestSendPathsNormalizeIds(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:allowlist/set", []subdto.PlayerDto{})
+	f.Result("minecraft:bans/add", []subdto.UserBanDto{})
+	f.Result("minecraft:ip_bans/add", []subdto.IpBanDTO{})
+	cli := newTestClient(t, f, nil)
+	ctx := testContext(t)
+
+	// 无效Id直接返回错误，不发送请求
+	if _, err := cli.SetAllowlist(ctx, []subdto.PlayerDto{{Id: "8484", Name: "wdwd"}}); err == nil {
+		t.Fatal("expected error for invalid uuid")
+	}
+	if err := cli.OperatorsAdd(subdto.OperatorDto{Player: subdto.PlayerDto{Id: "8484"}}); err == nil {
+		t.Fatal("expected error for invalid uuid")
+	}
+	if err := cli.IpBansRemove("10.0.0"); err == nil {
+		t.Fatal("expected error for invalid ip")
+	}
+	if n := len(f.Calls()); n != 0 {
+		t.Fatalf("sent %d requests with invalid ids", n)
+	}
+
+	// 有效Id被转换为小写带横线的标准形式
+	player := subdto.PlayerDto{Id: "853C80EF3C3749FDAA49938B674ADAE6", Name: "jeb_"}
+	if _, err := cli.BanFor(ctx, player, 0, "Griefing"); err != nil {
+		t.Fatal(err)
+	}
+	calls := f.CallsOf("minecraft:bans/add")
+	if len(calls) != 1 {
+		t.Fatalf("bans/add calls = %d", len(calls))
+	}
+	var args []subdto.UserBanDto
+	if err := json.Unmarshal(calls[0].Params.(json.RawMessage), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args[0].Player.Id != "853c80ef-3c37-49fd-aa49-938b674adae6" {
+		t.Fatalf("sent id %q", args[0].Player.Id)
+	}
+
+	// IP被转换为标准形式
+	if _, err := cli.IpBanFor(ctx, "0:0:0:0:0:0:0:1", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	calls = f.CallsOf("minecraft:ip_bans/add")
+	var ipArgs []subdto.IpBanDTO
+	if len(calls) != 1 || json.Unmarshal(calls[0].Params.(json.RawMessage), &ipArgs) != nil || ipArgs[0].Ip != "::1" {
+		t.Fatalf("ip_bans/add calls = %+v", calls)
+	}
+}
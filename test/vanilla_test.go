@@ -0,0 +1,208 @@
+package test
+
+import (
+	"bytes"
+	"github.com/CycleZero/mc-msmp-go/cmd"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"github.com/CycleZero/mc-msmp-go/vanilla"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const whitelistJSON = `[
+  {
+    "uuid": "853c80ef-3c37-49fd-aa49-938b674adae6",
+    "name": "jeb_"
+  },
+  {
+    "uuid": "61699b2e-d327-4a01-9f1e-0ea8c3f06bc6",
+    "name": "Dinnerbone"
+  }
+]
+`
+
+func TestWhitelistRoundTrip(t *testing.T) {
+	players, err := vanilla.ReadWhitelist(bytes.NewBufferString(whitelistJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 2 || players[0].Id != "853c80ef-3c37-49fd-aa49-938b674adae6" || players[1].Name != "Dinnerbone" {
+		t.Fatalf("unexpected players %+v", players)
+	}
+
+	path := filepath.Join(t.TempDir(), vanilla.WhitelistFileName)
+	if err := vanilla.SaveWhitelistFile(path, players); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := vanilla.WriteWhitelist(&buf, players); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != whitelistJSON {
+		t.Fatalf("written file differs:\n%s", buf.String())
+	}
+	loaded, err := vanilla.LoadWhitelistFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[1] != players[1] {
+		t.Fatalf("unexpected loaded players %+v", loaded)
+	}
+}
+
+const bannedPlayersJSON = `[
+  {
+    "uuid": "853c80ef-3c37-49fd-aa49-938b674adae6",
+    "name": "jeb_",
+    "created": "2024-03-01 18:30:00 +0100",
+    "source": "Notch",
+    "expires": "forever",
+    "reason": "Griefing"
+  },
+  {
+    "uuid": "61699b2e-d327-4a01-9f1e-0ea8c3f06bc6",
+    "name": "Dinnerbone",
+    "created": "2024-03-02 10:00:00 +0000",
+    "source": "Server",
+    "expires": "2024-04-02 10:00:00 +0000",
+    "reason": "Banned by an operator."
+  }
+]
+`
+
+const bannedIpsJSON = `[
+  {
+    "ip": "192.168.0.10",
+    "created": "2024-03-01 18:30:00 +0100",
+    "source": "Notch",
+    "expires": "forever",
+    "reason": "Alt accounts"
+  }
+]
+`
+
+func TestBannedPlayersRoundTrip(t *testing.T) {
+	entries, err := vanilla.ReadBannedPlayers(bytes.NewBufferString(bannedPlayersJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := vanilla.WriteBannedPlayers(&buf, entries); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != bannedPlayersJSON {
+		t.Fatalf("written file differs:\n%s", buf.String())
+	}
+
+	bans := vanilla.UserBans(entries)
+	if bans[0].Player.Name != "jeb_" || bans[0].Reason != "Griefing" || bans[0].Source != "Notch" {
+		t.Fatalf("unexpected ban %+v", bans[0])
+	}
+
+	// 转换回原版格式时保留已有记录的 created
+	back := vanilla.BannedPlayerEntries(bans, entries, time.Now())
+	buf.Reset()
+	if err := vanilla.WriteBannedPlayers(&buf, back); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != bannedPlayersJSON {
+		t.Fatalf("protocol round trip differs:\n%s", buf.String())
+	}
+}
+
+func TestBannedIpsRoundTrip(t *testing.T) {
+	entries, err := vanilla.ReadBannedIps(bytes.NewBufferString(bannedIpsJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bans := vanilla.IpBans(entries)
+	if bans[0].Ip != "192.168.0.10" {
+		t.Fatalf("unexpected ban %+v", bans[0])
+	}
+	back := vanilla.BannedIpEntries(bans, entries, time.Now())
+	var buf bytes.Buffer
+	if err := vanilla.WriteBannedIps(&buf, back); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != bannedIpsJSON {
+		t.Fatalf("protocol round trip differs:\n%s", buf.String())
+	}
+
+	// 没有旧记录时使用传入的时间
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fresh := vanilla.BannedIpEntries(bans, nil, created)
+	if fresh[0].Created.String() != "2024-05-01 12:00:00 +0000" {
+		t.Fatalf("unexpected created %q", fresh[0].Created)
+	}
+}
+
+func TestBannedPlayersLossless(t *testing.T) {
+	const file = `[
+  {
+    "uuid": "853c80ef-3c37-49fd-aa49-938b674adae6",
+    "name": "jeb_",
+    "created": "2023-12-24 08:15:00 +0000",
+    "source": "",
+    "expires": "forever",
+    "reason": ""
+  }
+]
+`
+	entries, err := vanilla.ReadBannedPlayers(bytes.NewBufferString(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 空的 source 和 reason 保持为空，created 从旧记录中保留
+	back := vanilla.BannedPlayerEntries(vanilla.UserBans(entries), entries, time.Now())
+	var buf bytes.Buffer
+	if err := vanilla.WriteBannedPlayers(&buf, back); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != file {
+		t.Fatalf("protocol round trip differs:\n%s", buf.String())
+	}
+}
+
+func TestOperatorsSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), vanilla.OpsFileName)
+	desired := []subdto.OperatorDto{
+		{PermissionLevel: 4, Player: subdto.PlayerDto{Id: "853c80ef-3c37-49fd-aa49-938b674adae6", Name: "jeb_"}},
+		{PermissionLevel: 2, BypassesPlayerLimit: true, Player: subdto.PlayerDto{Id: "61699b2e-d327-4a01-9f1e-0ea8c3f06bc6", Name: "Dinnerbone"}},
+	}
+	if err := vanilla.SaveOpsFile(path, desired); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := vanilla.LoadOpsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[1] != desired[1] {
+		t.Fatalf("unexpected ops %+v", loaded)
+	}
+
+	f := newFakeServer(t)
+	f.Result("minecraft:operators", []subdto.OperatorDto{
+		{PermissionLevel: 2, Player: subdto.PlayerDto{Id: "853c80ef-3c37-49fd-aa49-938b674adae6", Name: "jeb_"}},
+		{PermissionLevel: 4, Player: subdto.PlayerDto{Id: "069a79f4-44e9-4726-a5be-fca90e38aaf5", Name: "Notch"}},
+	})
+	f.Result("minecraft:operators/set", desired)
+	cli := newTestClient(t, f, nil)
+
+	report, err := cmd.OperatorsSync(testContext(t), cli, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Added) != 1 || report.Added[0].Player.Name != "Dinnerbone" {
+		t.Fatalf("unexpected added %+v", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Player.Name != "Notch" {
+		t.Fatalf("unexpected removed %+v", report.Removed)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Before.PermissionLevel != 2 || report.Changed[0].After.PermissionLevel != 4 {
+		t.Fatalf("unexpected changed %+v", report.Changed)
+	}
+	if n := len(f.CallsOf("minecraft:operators/set")); n != 1 {
+		t.Fatalf("operators/set called %d times", n)
+	}
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSaveAndWait(t *testing.T) {
+	f := fakeStoppableServer(t)
+	// 轮询间隔足够长，只能通过 saving/saved 通知返回
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Hour})
+
+	if err := cli.SaveAndWait(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.CallsOf("minecraft:server/status")) != 0 {
+		t.Fatal("unexpected status request")
+	}
+}
+
+func TestSaveAndWaitIgnoresStaleSaved(t *testing.T) {
+	f := newFakeServer(t)
+	// 响应之前先到达一条之前的保存遗留的 saved 通知，本次保存没有 saving 通知
+	f.Handle("minecraft:server/save", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		f.Notify(mcmsmpgo.NotificationServerSaved)
+		return true, nil
+	})
+	f.Result("minecraft:server/status", subdto.ServerState{Started: true})
+	clk := newFakeClock(time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC))
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Second, Clock: clk})
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.SaveAndWait(testContext(t))
+	}()
+	clk.WaitForTimer(t)
+	select {
+	case err := <-done:
+		t.Fatalf("stale saved notification completed the wait: %v", err)
+	default:
+	}
+	// 轮询间隔内没有 saving 通知，退回到轮询状态
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.CallsOf("minecraft:server/status")); n != 1 {
+		t.Fatalf("status polled %d times", n)
+	}
+
+	// 遗留的 saved 之后依次收到本次保存的 saving 和 saved
+	f.Handle("minecraft:server/save", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		f.Notify(mcmsmpgo.NotificationServerSaved)
+		f.Notify(mcmsmpgo.NotificationServerSaving)
+		f.Notify(mcmsmpgo.NotificationServerSaved)
+		return true, nil
+	})
+	cli = newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Hour})
+	if err := cli.SaveAndWait(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveAndWaitWithoutNotifications(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/save", true)
+	// 前两次状态请求失败，第三次成功
+	var polls atomic.Int32
+	f.Handle("minecraft:server/status", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		if polls.Add(1) < 3 {
+			return nil, &dto.MsmpResponseError{Code: -32603, Message: "busy"}
+		}
+		return subdto.ServerState{Started: true}, nil
+	})
+	clk := newFakeClock(time.Date(2025, 6, 1, 4, 0, 0, 0, time.UTC))
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Second, Clock: clk})
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.SaveAndWait(testContext(t))
+	}()
+	clk.WaitForTimer(t)
+	clk.Advance(time.Second)
+	clk.WaitForTicker(t)
+	for want := int32(2); want <= 3; want++ {
+		clk.Advance(time.Second)
+		eventually(t, func() bool { return polls.Load() >= want })
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Fatalf("status polled %d times", n)
+	}
+	// 保存请求带有 flush 参数
+	if params := string(f.CallsOf("minecraft:server/save")[0].Params.(json.RawMessage)); params != "[true]" {
+		t.Fatalf("save params = %s", params)
+	}
+}
+
+func TestSaveAndWaitUnconfirmed(t *testing.T) {
+	f := fakeStoppableServer(t)
+	f.Result("minecraft:server/save", false)
+	cli := newTestClient(t, f, nil)
+
+	if err := cli.SaveAndWait(testContext(t)); !errors.Is(err, mcmsmpgo.ErrSaveUnconfirmed) {
+		t.Fatalf("expected ErrSaveUnconfirmed, got %v", err)
+	}
+	// 停服不因无法确认的保存中止，但要把错误返回给调用方
+	if err := cli.ScheduleStop(testContext(t), 0, mcmsmpgo.StopOptions{}); !errors.Is(err, mcmsmpgo.ErrSaveUnconfirmed) {
+		t.Fatalf("expected ErrSaveUnconfirmed, got %v", err)
+	}
+	if n := len(f.CallsOf("minecraft:server/stop")); n != 1 {
+		t.Fatalf("server/stop called %d times", n)
+	}
+}
+
+func TestStopAndWaitPolling(t *testing.T) {
+	f := newFakeServer(t)
+	f.Result("minecraft:server/stop", true)
+	f.Result("minecraft:server/status", subdto.ServerState{Started: false})
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: 20 * time.Millisecond})
+
+	if err := cli.StopAndWait(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitUntilStarted(t *testing.T) {
+	f := newFakeServer(t)
+	var polls atomic.Int32
+	f.Handle("minecraft:server/status", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return subdto.ServerState{Started: false}, nil
+	})
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Hour})
+
+	// 通过通知得知启动完成：第一次轮询之后订阅已经建立，此时发出通知
+	done := make(chan error, 1)
+	go func() {
+		done <- cli.WaitUntilStarted(testContext(t))
+	}()
+	eventually(t, func() bool {
+		return len(f.CallsOf("minecraft:server/status")) == 1
+	})
+	f.Notify(mcmsmpgo.NotificationServerStarted)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有通知时通过轮询得知启动完成，第三次轮询时服务端已启动
+	f.Handle("minecraft:server/status", func(json.RawMessage) (interface{}, *dto.MsmpResponseError) {
+		return subdto.ServerState{Started: polls.Add(1) >= 3}, nil
+	})
+	polling := newTestClient(t, f, &mcmsmpgo.NewClientConfig{PollInterval: time.Millisecond})
+	if err := polling.WaitUntilStarted(testContext(t)); err != nil {
+		t.Fatal(err)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Fatalf("status polled %d times", n)
+	}
+}
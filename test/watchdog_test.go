@@ -0,0 +1,142 @@
+package test
+
+import (
+	"errors"
+	mcmsmpgo "github.com/CycleZero/mc-msmp-go"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	f := newFakeServer(t)
+	setting := statefulSettings(f, map[string]interface{}{mcmsmpgo.SettingStatusHeartbeatInterval: 0})
+	cli := newTestClient(t, f, nil)
+	cli.SetAutoReconnect(false)
+	ctx := testContext(t)
+	clock := newFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	disabled := mcmsmpgo.NewWatchdog(cli, mcmsmpgo.WatchdogConfig{})
+	if err := disabled.Start(ctx); !errors.Is(err, mcmsmpgo.ErrHeartbeatDisabled) {
+		t.Fatalf("err = %v", err)
+	}
+
+	var mutex sync.Mutex
+	var alerts []mcmsmpgo.WatchdogState
+	lastAlert := func() mcmsmpgo.WatchdogState {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if len(alerts) == 0 {
+			return mcmsmpgo.WatchdogHealthy
+		}
+		return alerts[len(alerts)-1]
+	}
+	// 检查间隔和时间都由手动推进的时钟决定
+	watchdog := mcmsmpgo.NewWatchdog(cli, mcmsmpgo.WatchdogConfig{
+		Interval: 500 * time.Millisecond,
+		Timeout:  3 * time.Second,
+		Clock:    clock,
+		OnAlert: func(a mcmsmpgo.WatchdogAlert) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			alerts = append(alerts, a.State)
+		},
+	})
+	if err := watchdog.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	clock.WaitForTicker(t)
+	if got := setting(mcmsmpgo.SettingStatusHeartbeatInterval); got != "1" {
+		t.Fatalf("heartbeat interval = %s", got)
+	}
+	// 重复启动不会修改设置
+	sets := len(f.CallsOf("minecraft:serversettings/" + mcmsmpgo.SettingStatusHeartbeatInterval + "/set"))
+	if err := watchdog.Start(ctx); err == nil {
+		t.Fatal("expected already started error")
+	}
+	if n := len(f.CallsOf("minecraft:serversettings/" + mcmsmpgo.SettingStatusHeartbeatInterval + "/set")); n != sets {
+		t.Fatalf("set calls = %d, want %d", n, sets)
+	}
+
+	beat := func() {
+		f.Notify(mcmsmpgo.NotificationServerStatus, subdto.ServerState{Started: true})
+		eventually(t, func() bool { return watchdog.LastHeartbeat().Equal(clock.Now()) })
+	}
+	clock.Advance(time.Second)
+	beat()
+	if lastAlert() != mcmsmpgo.WatchdogHealthy {
+		t.Fatalf("alerts = %v", alerts)
+	}
+
+	// 心跳停止但连接正常
+	clock.Advance(4 * time.Second)
+	eventually(t, func() bool { return lastAlert() == mcmsmpgo.WatchdogServerHung })
+	beat()
+	eventually(t, func() bool { return lastAlert() == mcmsmpgo.WatchdogHealthy })
+
+	f.Drop()
+	eventually(t, func() bool { return !cli.IsConnected() })
+	clock.Advance(time.Second)
+	eventually(t, func() bool { return lastAlert() == mcmsmpgo.WatchdogConnectionLost })
+
+	// 停止时恢复原来的心跳间隔
+	if err := cli.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := watchdog.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := setting(mcmsmpgo.SettingStatusHeartbeatInterval); got != "0" {
+		t.Fatalf("heartbeat interval after stop = %s", got)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	want := []mcmsmpgo.WatchdogState{mcmsmpgo.WatchdogServerHung, mcmsmpgo.WatchdogHealthy, mcmsmpgo.WatchdogConnectionLost}
+	if len(alerts) != len(want) {
+		t.Fatalf("alerts = %v", alerts)
+	}
+	for i := range want {
+		if alerts[i] != want[i] {
+			t.Fatalf("alerts = %v", alerts)
+		}
+	}
+}
+
+func TestWatchdogSilentServer(t *testing.T) {
+	f := newFakeServer(t)
+	statefulSettings(f, map[string]interface{}{mcmsmpgo.SettingStatusHeartbeatInterval: 1})
+	cli := newTestClient(t, f, &mcmsmpgo.NewClientConfig{ReadTimeout: 100 * time.Millisecond})
+	ctx := testContext(t)
+	clock := newFakeClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	alerts := make(chan mcmsmpgo.WatchdogState, 4)
+	watchdog := mcmsmpgo.NewWatchdog(cli, mcmsmpgo.WatchdogConfig{
+		Clock: clock,
+		OnAlert: func(a mcmsmpgo.WatchdogAlert) {
+			alerts <- a.State
+		},
+	})
+	if err := watchdog.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer watchdog.Stop()
+	clock.WaitForTicker(t)
+
+	// 服务端超过读取期限没有发送任何消息，连接靠ping/pong保持
+	time.Sleep(500 * time.Millisecond)
+	if !cli.IsConnected() {
+		t.Fatal("silent connection was closed")
+	}
+
+	clock.Advance(4 * time.Second)
+	select {
+	case state := <-alerts:
+		if state != mcmsmpgo.WatchdogServerHung {
+			t.Fatalf("state = %v, want server hung", state)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no alert")
+	}
+}
@@ -0,0 +1,323 @@
+package text
+
+import (
+	"errors"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"strings"
+)
+
+// ErrEventsNotSupported 协议中的消息无法表示点击与悬停事件
+var ErrEventsNotSupported = errors.New("click and hover events cannot be represented in a protocol message")
+
+// Color 文本颜色，可以是命名颜色或 "#RRGGBB"
+type Color string
+
+const (
+	Black       Color = "black"
+	DarkBlue    Color = "dark_blue"
+	DarkGreen   Color = "dark_green"
+	DarkAqua    Color = "dark_aqua"
+	DarkRed     Color = "dark_red"
+	DarkPurple  Color = "dark_purple"
+	Gold        Color = "gold"
+	Gray        Color = "gray"
+	DarkGray    Color = "dark_gray"
+	Blue        Color = "blue"
+	Green       Color = "green"
+	Aqua        Color = "aqua"
+	Red         Color = "red"
+	LightPurple Color = "light_purple"
+	Yellow      Color = "yellow"
+	White       Color = "white"
+)
+
+// legacyColorCodes 命名颜色对应的 § 格式代码
+var legacyColorCodes = map[Color]byte{
+	Black: '0', DarkBlue: '1', DarkGreen: '2', DarkAqua: '3',
+	DarkRed: '4', DarkPurple: '5', Gold: '6', Gray: '7',
+	DarkGray: '8', Blue: '9', Green: 'a', Aqua: 'b',
+	Red: 'c', LightPurple: 'd', Yellow: 'e', White: 'f',
+}
+
+// ClickEvent 点击事件，按 1.21.5 起的格式，不同动作使用不同的字段
+type ClickEvent struct {
+	Action string `json:"action"`
+	// open_url 使用
+	URL string `json:"url,omitempty"`
+	// run_command 和 suggest_command 使用
+	Command string `json:"command,omitempty"`
+	// copy_to_clipboard 使用
+	Value string `json:"value,omitempty"`
+}
+
+// HoverEvent 悬停事件，按 1.21.5 起的格式
+type HoverEvent struct {
+	Action string     `json:"action"`
+	Value  *Component `json:"value"`
+}
+
+// Component 原版文本组件，使用链式方法构造
+// 序列化为 1.21.5 起的JSON格式（click_event/hover_event），不兼容更早的版本
+type Component struct {
+	Text          string      `json:"text,omitempty"`
+	Translate     string      `json:"translate,omitempty"`
+	With          []Component `json:"with,omitempty"`
+	Color         Color       `json:"color,omitempty"`
+	Bold          *bool       `json:"bold,omitempty"`
+	Italic        *bool       `json:"italic,omitempty"`
+	Underlined    *bool       `json:"underlined,omitempty"`
+	Strikethrough *bool       `json:"strikethrough,omitempty"`
+	Obfuscated    *bool       `json:"obfuscated,omitempty"`
+	ClickEvent    *ClickEvent `json:"click_event,omitempty"`
+	HoverEvent    *HoverEvent `json:"hover_event,omitempty"`
+	Extra         []Component `json:"extra,omitempty"`
+}
+
+// Text 创建纯文本组件
+func Text(s string) *Component {
+	return &Component{Text: s}
+}
+
+// Translate 创建可翻译组件，args 依次替换翻译文本中的 %s
+func Translate(key string, args ...*Component) *Component {
+	c := &Component{Translate: key}
+	for _, a := range args {
+		c.With = append(c.With, *a)
+	}
+	return c
+}
+
+func enabled() *bool {
+	v := true
+	return &v
+}
+
+// WithColor 设置颜色
+func (c *Component) WithColor(color Color) *Component {
+	c.Color = color
+	return c
+}
+
+// Bolded 加粗
+func (c *Component) Bolded() *Component {
+	c.Bold = enabled()
+	return c
+}
+
+// Italicized 斜体
+func (c *Component) Italicized() *Component {
+	c.Italic = enabled()
+	return c
+}
+
+// Underline 下划线
+func (c *Component) Underline() *Component {
+	c.Underlined = enabled()
+	return c
+}
+
+// Strike 删除线
+func (c *Component) Strike() *Component {
+	c.Strikethrough = enabled()
+	return c
+}
+
+// Obfuscate 随机字符
+func (c *Component) Obfuscate() *Component {
+	c.Obfuscated = enabled()
+	return c
+}
+
+// OpenURL 点击时打开链接
+func (c *Component) OpenURL(url string) *Component {
+	c.ClickEvent = &ClickEvent{Action: "open_url", URL: url}
+	return c
+}
+
+// RunCommand 点击时执行命令
+func (c *Component) RunCommand(command string) *Component {
+	c.ClickEvent = &ClickEvent{Action: "run_command", Command: command}
+	return c
+}
+
+// SuggestCommand 点击时将命令填入聊天栏
+func (c *Component) SuggestCommand(command string) *Component {
+	c.ClickEvent = &ClickEvent{Action: "suggest_command", Command: command}
+	return c
+}
+
+// CopyToClipboard 点击时复制文本
+func (c *Component) CopyToClipboard(value string) *Component {
+	c.ClickEvent = &ClickEvent{Action: "copy_to_clipboard", Value: value}
+	return c
+}
+
+// HoverText 悬停时显示文本
+func (c *Component) HoverText(hover *Component) *Component {
+	c.HoverEvent = &HoverEvent{Action: "show_text", Value: hover}
+	return c
+}
+
+// Append 追加子组件，子组件继承当前组件的样式
+func (c *Component) Append(children ...*Component) *Component {
+	for _, child := range children {
+		c.Extra = append(c.Extra, *child)
+	}
+	return c
+}
+
+// style 渲染 § 格式代码时累积的样式
+type style struct {
+	color         Color
+	bold          bool
+	italic        bool
+	underlined    bool
+	strikethrough bool
+	obfuscated    bool
+}
+
+func (s style) inherit(c *Component) style {
+	if c.Color != "" {
+		s.color = c.Color
+	}
+	apply := func(dst *bool, v *bool) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	apply(&s.bold, c.Bold)
+	apply(&s.italic, c.Italic)
+	apply(&s.underlined, c.Underlined)
+	apply(&s.strikethrough, c.Strikethrough)
+	apply(&s.obfuscated, c.Obfuscated)
+	return s
+}
+
+// codes 返回样式对应的 § 代码，颜色代码在前，因为颜色代码会清除之前的格式
+func (s style) codes() string {
+	var b strings.Builder
+	if code, ok := legacyColorCodes[s.color]; ok {
+		b.WriteString("§")
+		b.WriteByte(code)
+	}
+	for _, f := range []struct {
+		on   bool
+		code byte
+	}{{s.obfuscated, 'k'}, {s.bold, 'l'}, {s.strikethrough, 'm'}, {s.underlined, 'n'}, {s.italic, 'o'}} {
+		if f.on {
+			b.WriteString("§")
+			b.WriteByte(f.code)
+		}
+	}
+	return b.String()
+}
+
+type renderer struct {
+	b      strings.Builder
+	last   style
+	styled bool
+}
+
+func (r *renderer) write(s style, text string) {
+	if text == "" {
+		return
+	}
+	if r.styled && s != r.last {
+		if s == (style{}) {
+			r.b.WriteString("§r")
+		} else {
+			r.b.WriteString("§r" + s.codes())
+		}
+	} else if !r.styled && s != (style{}) {
+		r.b.WriteString(s.codes())
+	}
+	r.styled = r.styled || s != (style{})
+	r.last = s
+	r.b.WriteString(text)
+}
+
+func (r *renderer) render(c *Component, parent style, legacy bool) {
+	s := parent.inherit(c)
+	if !legacy {
+		s = style{}
+	}
+	if c.Translate != "" {
+		// 无法在客户端以外解析翻译，按 "key arg1 arg2" 输出
+		r.write(s, c.Translate)
+		for i := range c.With {
+			r.write(s, " ")
+			r.render(&c.With[i], s, legacy)
+		}
+	} else {
+		r.write(s, c.Text)
+	}
+	for i := range c.Extra {
+		r.render(&c.Extra[i], s, legacy)
+	}
+}
+
+// PlainText 返回去除样式后的文本
+func (c *Component) PlainText() string {
+	r := &renderer{}
+	r.render(c, style{}, false)
+	return r.b.String()
+}
+
+// Legacy 返回使用 § 格式代码表示颜色与格式的文本，"#RRGGBB" 颜色和点击/悬停事件无法表示，会被忽略
+func (c *Component) Legacy() string {
+	r := &renderer{}
+	r.render(c, style{}, true)
+	return r.b.String()
+}
+
+// HasEvents 组件或其子组件是否带有点击或悬停事件
+func (c *Component) HasEvents() bool {
+	if c.ClickEvent != nil || c.HoverEvent != nil {
+		return true
+	}
+	for i := range c.With {
+		if c.With[i].HasEvents() {
+			return true
+		}
+	}
+	for i := range c.Extra {
+		if c.Extra[i].HasEvents() {
+			return true
+		}
+	}
+	return false
+}
+
+// StrictMessage 与 Message 相同，但组件带有点击或悬停事件时返回 ErrEventsNotSupported 而不是丢弃事件
+func (c *Component) StrictMessage() (subdto.Message, error) {
+	if c.HasEvents() {
+		return subdto.Message{}, ErrEventsNotSupported
+	}
+	return c.Message(), nil
+}
+
+// Message 转换为协议中的消息
+// 不带样式和子组件的可翻译组件转换为 translatable，其余转换为带 § 格式代码的 literal。
+// 注意：协议的消息格式不支持点击与悬停事件，Message 会丢弃这些事件，
+// 需要在丢弃时得到错误请使用 StrictMessage，事件只在作为JSON文本组件使用时生效。
+func (c *Component) Message() subdto.Message {
+	if c.Translate != "" && len(c.Extra) == 0 && c.Color == "" && c.Bold == nil && c.Italic == nil &&
+		c.Underlined == nil && c.Strikethrough == nil && c.Obfuscated == nil {
+		m := subdto.Message{Translatable: c.Translate}
+		for i := range c.With {
+			m.TranslatableParams = append(m.TranslatableParams, c.With[i].Legacy())
+		}
+		return m
+	}
+	return subdto.Message{Literal: c.Legacy()}
+}
+
+// SystemMessage 构造系统消息，players为空时发送给所有玩家，overlay为true时显示在动作栏
+// 与 Message 一样会丢弃点击与悬停事件
+func (c *Component) SystemMessage(overlay bool, players ...subdto.PlayerDto) subdto.SystemMessageDto {
+	return subdto.SystemMessageDto{
+		Message:          c.Message(),
+		Overlay:          overlay,
+		ReceivingPlayers: players,
+	}
+}
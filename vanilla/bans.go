@@ -0,0 +1,224 @@
+package vanilla
+
+import (
+	"encoding/json"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"io"
+	"time"
+)
+
+const (
+	// BannedPlayersFileName 原版封禁玩家文件名
+	BannedPlayersFileName = "banned-players.json"
+	// BannedIpsFileName 原版封禁IP文件名
+	BannedIpsFileName = "banned-ips.json"
+)
+
+// BannedPlayerEntry 原版 banned-players.json 中的一条记录
+type BannedPlayerEntry struct {
+	UUID    string           `json:"uuid"`
+	Name    string           `json:"name"`
+	Created Time             `json:"created"`
+	Source  string           `json:"source"`
+	Expires subdto.BanExpiry `json:"expires"`
+	Reason  string           `json:"reason"`
+}
+
+// MarshalJSON 按原版格式写出，expires 使用原版的时间格式和 "forever"
+func (e BannedPlayerEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		UUID    string `json:"uuid"`
+		Name    string `json:"name"`
+		Created Time   `json:"created"`
+		Source  string `json:"source"`
+		Expires string `json:"expires"`
+		Reason  string `json:"reason"`
+	}{e.UUID, e.Name, e.Created, e.Source, formatExpires(e.Expires), e.Reason})
+}
+
+// ToUserBan 转换为协议使用的封禁结构，协议中没有 created，需要保留时转换回来时使用 BannedPlayerEntries
+func (e BannedPlayerEntry) ToUserBan() subdto.UserBanDto {
+	return subdto.UserBanDto{
+		Expires: e.Expires,
+		Player:  subdto.PlayerDto{Id: e.UUID, Name: e.Name},
+		Reason:  e.Reason,
+		Source:  e.Source,
+	}
+}
+
+// NewBannedPlayerEntry 由协议封禁结构构造原版记录，created 为记录的创建时间
+// source 和 reason 原样保留，不填充默认值
+func NewBannedPlayerEntry(ban subdto.UserBanDto, created time.Time) BannedPlayerEntry {
+	return BannedPlayerEntry{
+		UUID:    ban.Player.Id,
+		Name:    ban.Player.Name,
+		Created: Time{Time: created},
+		Source:  ban.Source,
+		Expires: ban.Expires,
+		Reason:  ban.Reason,
+	}
+}
+
+// BannedIpEntry 原版 banned-ips.json 中的一条记录
+type BannedIpEntry struct {
+	Ip      string           `json:"ip"`
+	Created Time             `json:"created"`
+	Source  string           `json:"source"`
+	Expires subdto.BanExpiry `json:"expires"`
+	Reason  string           `json:"reason"`
+}
+
+// MarshalJSON 按原版格式写出，expires 使用原版的时间格式和 "forever"
+func (e BannedIpEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Ip      string `json:"ip"`
+		Created Time   `json:"created"`
+		Source  string `json:"source"`
+		Expires string `json:"expires"`
+		Reason  string `json:"reason"`
+	}{e.Ip, e.Created, e.Source, formatExpires(e.Expires), e.Reason})
+}
+
+// ToIpBan 转换为协议使用的IP封禁结构，协议中没有 created，需要保留时转换回来时使用 BannedIpEntries
+func (e BannedIpEntry) ToIpBan() subdto.IpBanDTO {
+	return subdto.IpBanDTO{
+		Expires: e.Expires,
+		Ip:      e.Ip,
+		Reason:  e.Reason,
+		Source:  e.Source,
+	}
+}
+
+// NewBannedIpEntry 由协议IP封禁结构构造原版记录，created 为记录的创建时间
+// source 和 reason 原样保留，不填充默认值
+func NewBannedIpEntry(ban subdto.IpBanDTO, created time.Time) BannedIpEntry {
+	return BannedIpEntry{
+		Ip:      ban.Ip,
+		Created: Time{Time: created},
+		Source:  ban.Source,
+		Expires: ban.Expires,
+		Reason:  ban.Reason,
+	}
+}
+
+// ReadBannedPlayers 读取原版 banned-players.json，保留全部字段
+func ReadBannedPlayers(r io.Reader) ([]BannedPlayerEntry, error) {
+	var entries []BannedPlayerEntry
+	if err := readJSON(r, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WriteBannedPlayers 以原版 banned-players.json 格式写出
+func WriteBannedPlayers(w io.Writer, entries []BannedPlayerEntry) error {
+	if entries == nil {
+		entries = []BannedPlayerEntry{}
+	}
+	return writeJSON(w, entries)
+}
+
+// ReadBannedIps 读取原版 banned-ips.json，保留全部字段
+func ReadBannedIps(r io.Reader) ([]BannedIpEntry, error) {
+	var entries []BannedIpEntry
+	if err := readJSON(r, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WriteBannedIps 以原版 banned-ips.json 格式写出
+func WriteBannedIps(w io.Writer, entries []BannedIpEntry) error {
+	if entries == nil {
+		entries = []BannedIpEntry{}
+	}
+	return writeJSON(w, entries)
+}
+
+// UserBans 将原版记录转换为协议封禁列表
+func UserBans(entries []BannedPlayerEntry) []subdto.UserBanDto {
+	bans := make([]subdto.UserBanDto, 0, len(entries))
+	for _, e := range entries {
+		bans = append(bans, e.ToUserBan())
+	}
+	return bans
+}
+
+// BannedPlayerEntries 将协议封禁列表转换为原版记录
+// previous 中已有的同一玩家记录保留原来的 created，其余使用 now
+func BannedPlayerEntries(bans []subdto.UserBanDto, previous []BannedPlayerEntry, now time.Time) []BannedPlayerEntry {
+	created := make(map[string]Time, len(previous))
+	for _, e := range previous {
+		created[e.UUID] = e.Created
+	}
+	entries := make([]BannedPlayerEntry, 0, len(bans))
+	for _, b := range bans {
+		e := NewBannedPlayerEntry(b, now)
+		if c, ok := created[e.UUID]; ok {
+			e.Created = c
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// IpBans 将原版记录转换为协议IP封禁列表
+func IpBans(entries []BannedIpEntry) []subdto.IpBanDTO {
+	bans := make([]subdto.IpBanDTO, 0, len(entries))
+	for _, e := range entries {
+		bans = append(bans, e.ToIpBan())
+	}
+	return bans
+}
+
+// BannedIpEntries 将协议IP封禁列表转换为原版记录
+// previous 中已有的同一IP记录保留原来的 created，其余使用 now
+func BannedIpEntries(bans []subdto.IpBanDTO, previous []BannedIpEntry, now time.Time) []BannedIpEntry {
+	created := make(map[string]Time, len(previous))
+	for _, e := range previous {
+		created[e.Ip] = e.Created
+	}
+	entries := make([]BannedIpEntry, 0, len(bans))
+	for _, b := range bans {
+		e := NewBannedIpEntry(b, now)
+		if c, ok := created[e.Ip]; ok {
+			e.Created = c
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// LoadBannedPlayersFile 从文件读取封禁玩家记录
+func LoadBannedPlayersFile(path string) ([]BannedPlayerEntry, error) {
+	var entries []BannedPlayerEntry
+	err := loadFile(path, func(r io.Reader) (err error) {
+		entries, err = ReadBannedPlayers(r)
+		return err
+	})
+	return entries, err
+}
+
+// SaveBannedPlayersFile 将封禁玩家记录写入文件
+func SaveBannedPlayersFile(path string, entries []BannedPlayerEntry) error {
+	return saveFile(path, func(w io.Writer) error {
+		return WriteBannedPlayers(w, entries)
+	})
+}
+
+// LoadBannedIpsFile 从文件读取封禁IP记录
+func LoadBannedIpsFile(path string) ([]BannedIpEntry, error) {
+	var entries []BannedIpEntry
+	err := loadFile(path, func(r io.Reader) (err error) {
+		entries, err = ReadBannedIps(r)
+		return err
+	})
+	return entries, err
+}
+
+// SaveBannedIpsFile 将封禁IP记录写入文件
+func SaveBannedIpsFile(path string, entries []BannedIpEntry) error {
+	return saveFile(path, func(w io.Writer) error {
+		return WriteBannedIps(w, entries)
+	})
+}
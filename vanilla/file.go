@@ -0,0 +1,48 @@
+package vanilla
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/fileutil"
+	"io"
+	"os"
+)
+
+// readJSON 读取原版列表文件，空文件视为空列表
+func readJSON(r io.Reader, v interface{}) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON 按原版服务端的格式（两个空格缩进）写出JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func loadFile(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return nil
+}
+
+// saveFile 原子地写入文件，保留原文件的权限
+func saveFile(path string, write func(io.Writer) error) error {
+	return fileutil.WriteFileAtomic(path, 0o644, write)
+}
@@ -0,0 +1,75 @@
+package vanilla
+
+import (
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"io"
+)
+
+// OpsFileName 原版管理员文件名
+const OpsFileName = "ops.json"
+
+// OpEntry 原版 ops.json 中的一条记录
+type OpEntry struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+// ToOperator 转换为协议使用的管理员结构
+func (e OpEntry) ToOperator() subdto.OperatorDto {
+	return subdto.OperatorDto{
+		BypassesPlayerLimit: e.BypassesPlayerLimit,
+		PermissionLevel:     e.Level,
+		Player:              subdto.PlayerDto{Id: e.UUID, Name: e.Name},
+	}
+}
+
+// NewOpEntry 由协议管理员结构构造原版记录
+func NewOpEntry(operator subdto.OperatorDto) OpEntry {
+	return OpEntry{
+		UUID:                operator.Player.Id,
+		Name:                operator.Player.Name,
+		Level:               operator.PermissionLevel,
+		BypassesPlayerLimit: operator.BypassesPlayerLimit,
+	}
+}
+
+// ReadOps 读取原版 ops.json 格式的管理员列表
+func ReadOps(r io.Reader) ([]subdto.OperatorDto, error) {
+	var entries []OpEntry
+	if err := readJSON(r, &entries); err != nil {
+		return nil, err
+	}
+	operators := make([]subdto.OperatorDto, 0, len(entries))
+	for _, e := range entries {
+		operators = append(operators, e.ToOperator())
+	}
+	return operators, nil
+}
+
+// WriteOps 以原版 ops.json 格式写出管理员列表
+func WriteOps(w io.Writer, operators []subdto.OperatorDto) error {
+	entries := make([]OpEntry, 0, len(operators))
+	for _, o := range operators {
+		entries = append(entries, NewOpEntry(o))
+	}
+	return writeJSON(w, entries)
+}
+
+// LoadOpsFile 从文件读取管理员列表
+func LoadOpsFile(path string) ([]subdto.OperatorDto, error) {
+	var operators []subdto.OperatorDto
+	err := loadFile(path, func(r io.Reader) (err error) {
+		operators, err = ReadOps(r)
+		return err
+	})
+	return operators, err
+}
+
+// SaveOpsFile 将管理员列表写入文件
+func SaveOpsFile(path string, operators []subdto.OperatorDto) error {
+	return saveFile(path, func(w io.Writer) error {
+		return WriteOps(w, operators)
+	})
+}
@@ -0,0 +1,68 @@
+package vanilla
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"time"
+)
+
+const (
+	// TimeLayout 原版列表文件中 created/expires 使用的时间格式（yyyy-MM-dd HH:mm:ss Z）
+	TimeLayout = subdto.VanillaTimeLayout
+	// Forever 原版文件中表示永久封禁的 expires 值
+	Forever = subdto.ForeverExpires
+)
+
+// Time 原版列表文件中的 created 时间，零值序列化为空字符串
+// expires 直接使用 subdto.BanExpiry，由记录按原版格式写出
+type Time struct {
+	time.Time
+}
+
+// ParseTime 解析原版文件中的时间
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(TimeLayout, s)
+}
+
+// FormatTime 按原版文件的格式输出时间
+func FormatTime(t time.Time) string {
+	return t.Format(TimeLayout)
+}
+
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return FormatTime(t.Time)
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON null和空字符串解析为零值，"forever" 只用于 expires，出现在这里时报错
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == nil || *s == "" {
+		*t = Time{}
+		return nil
+	}
+	parsed, err := ParseTime(*s)
+	if err != nil {
+		return fmt.Errorf("invalid time %q: %v", *s, err)
+	}
+	*t = Time{Time: parsed}
+	return nil
+}
+
+// formatExpires 按原版文件的格式输出 expires，永久封禁为 "forever"
+func formatExpires(e subdto.BanExpiry) string {
+	if e.Permanent() {
+		return Forever
+	}
+	return FormatTime(e.Time)
+}
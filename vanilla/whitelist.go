@@ -0,0 +1,64 @@
+package vanilla
+
+import (
+	"github.com/CycleZero/mc-msmp-go/dto/subdto"
+	"io"
+)
+
+// WhitelistFileName 原版白名单文件名
+const WhitelistFileName = "whitelist.json"
+
+// WhitelistEntry 原版 whitelist.json 中的一条记录
+type WhitelistEntry struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// ToPlayer 转换为协议使用的玩家结构
+func (e WhitelistEntry) ToPlayer() subdto.PlayerDto {
+	return subdto.PlayerDto{Id: e.UUID, Name: e.Name}
+}
+
+// NewWhitelistEntry 由玩家结构构造原版记录
+func NewWhitelistEntry(player subdto.PlayerDto) WhitelistEntry {
+	return WhitelistEntry{UUID: player.Id, Name: player.Name}
+}
+
+// ReadWhitelist 读取原版 whitelist.json 格式的白名单
+func ReadWhitelist(r io.Reader) ([]subdto.PlayerDto, error) {
+	var entries []WhitelistEntry
+	if err := readJSON(r, &entries); err != nil {
+		return nil, err
+	}
+	players := make([]subdto.PlayerDto, 0, len(entries))
+	for _, e := range entries {
+		players = append(players, e.ToPlayer())
+	}
+	return players, nil
+}
+
+// WriteWhitelist 以原版 whitelist.json 格式写出白名单
+func WriteWhitelist(w io.Writer, players []subdto.PlayerDto) error {
+	entries := make([]WhitelistEntry, 0, len(players))
+	for _, p := range players {
+		entries = append(entries, NewWhitelistEntry(p))
+	}
+	return writeJSON(w, entries)
+}
+
+// LoadWhitelistFile 从文件读取白名单
+func LoadWhitelistFile(path string) ([]subdto.PlayerDto, error) {
+	var players []subdto.PlayerDto
+	err := loadFile(path, func(r io.Reader) (err error) {
+		players, err = ReadWhitelist(r)
+		return err
+	})
+	return players, err
+}
+
+// SaveWhitelistFile 将白名单写入文件
+func SaveWhitelistFile(path string, players []subdto.PlayerDto) error {
+	return saveFile(path, func(w io.Writer) error {
+		return WriteWhitelist(w, players)
+	})
+}
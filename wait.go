@@ -0,0 +1,146 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"time"
+)
+
+// ErrSaveUnconfirmed 服务端接受了保存请求，但表示没有开始保存
+var ErrSaveUnconfirmed = errors.New("save requested but not confirmed by the server")
+
+// SaveAndWait 保存世界并等待保存完成
+// 保存请求带有 flush 参数，服务端将数据写入磁盘后才响应。
+// 优先等待通知：只有先收到 saving 通知、再收到 saved 通知时才认为本次保存完成，没有对应 saving 的 saved 通知
+// （例如之前的保存遗留的通知）会被忽略。通知在发送请求之前订阅，收到的通知都不早于本次请求。
+// 轮询间隔内没有收到 saving 通知时（例如关闭了通知）退回到轮询 server/status，保存请求响应之后的状态请求成功时认为保存完成。
+// 服务端响应表示没有开始保存时返回 ErrSaveUnconfirmed。
+func (c *MsmpClient) SaveAndWait(ctx context.Context) error {
+	events, unsubscribe := c.notifications(4, NotificationServerSaving, NotificationServerSaved)
+	defer unsubscribe()
+	lost := c.ConnectionLost()
+	saving, err := Call[bool](ctx, c, "minecraft:server/save", true)
+	if err != nil {
+		return err
+	}
+	if !saving {
+		return ErrSaveUnconfirmed
+	}
+
+	clk := c.clockFor(ctx)
+	grace := clk.NewTimer(c.pollInterval)
+	defer grace.Stop()
+	var ticker clock.Ticker
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	var tick <-chan time.Time
+	started := false
+	for {
+		select {
+		case n := <-events:
+			switch {
+			case n.Method == NotificationServerSaving:
+				// 已经开始保存，继续等待 saved 通知
+				started = true
+				grace.Stop()
+			case started:
+				return nil
+			}
+		case <-grace.C():
+			if _, ok := c.pollStarted(ctx); ok {
+				return nil
+			}
+			ticker = clk.NewTicker(c.pollInterval)
+			tick = ticker.C()
+		case <-tick:
+			if _, ok := c.pollStarted(ctx); ok {
+				return nil
+			}
+		case <-lost:
+			return fmt.Errorf("wait for save: %w", ErrConnectionLost)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// StopAndWait 停止服务端并等待停止
+// 收到 stopping 通知、连接断开或轮询到服务端状态不再是已启动时返回。
+// 服务端可能在响应之前断开连接，所以不要求收到停止请求的响应。
+func (c *MsmpClient) StopAndWait(ctx context.Context) error {
+	stopping, unsubscribe := c.notifications(1, NotificationServerStopping)
+	defer unsubscribe()
+	lost := c.ConnectionLost()
+	p, err := c.Go("minecraft:server/stop", nil)
+	if err != nil {
+		return err
+	}
+	defer p.Cancel()
+
+	done := p.Done
+	ticker := c.clockFor(ctx).NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopping:
+			return nil
+		case <-lost:
+			return nil
+		case <-done:
+			// 服务端返回错误时不会停止；其他情况继续等待通知或断开
+			if p.Response != nil && p.Error != nil {
+				return fmt.Errorf("minecraft:server/stop: %w", p.Error)
+			}
+			done = nil
+		case <-ticker.C():
+			if started, ok := c.pollStarted(ctx); ok && !started {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// WaitUntilStarted 等待服务端启动完成
+// 收到 started 通知或轮询到服务端状态为已启动时返回；未连接时的轮询错误会被忽略，
+// 配合自动重连可以在服务端启动前开始等待。
+func (c *MsmpClient) WaitUntilStarted(ctx context.Context) error {
+	started, unsubscribe := c.notifications(1, NotificationServerStarted)
+	defer unsubscribe()
+	if ok, _ := c.pollStarted(ctx); ok {
+		return nil
+	}
+
+	ticker := c.clockFor(ctx).NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-started:
+			return nil
+		case <-ticker.C():
+			if ok, _ := c.pollStarted(ctx); ok {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// pollStarted 请求一次服务端状态，单次请求最多等待一个轮询间隔
+// 第二个返回值表示是否成功获取到状态
+func (c *MsmpClient) pollStarted(ctx context.Context) (started bool, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.pollInterval)
+	defer cancel()
+	status, err := c.GetServerStatus(ctx)
+	if err != nil {
+		return false, false
+	}
+	return status.Started, true
+}
@@ -0,0 +1,273 @@
+package mcmsmpgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/CycleZero/mc-msmp-go/clock"
+	"github.com/CycleZero/mc-msmp-go/dto"
+	"sync"
+	"time"
+)
+
+// ErrHeartbeatDisabled 服务端没有开启状态心跳，且没有配置心跳间隔
+var ErrHeartbeatDisabled = errors.New("status heartbeat is disabled on the server")
+
+// WatchdogState 看门狗判断的服务端状态
+type WatchdogState int
+
+const (
+	// WatchdogHealthy 按时收到心跳
+	WatchdogHealthy WatchdogState = iota
+	// WatchdogServerHung 连接正常但心跳停止，服务端主线程可能卡住
+	WatchdogServerHung
+	// WatchdogConnectionLost 连接断开
+	WatchdogConnectionLost
+)
+
+func (s WatchdogState) String() string {
+	switch s {
+	case WatchdogHealthy:
+		return "healthy"
+	case WatchdogServerHung:
+		return "server hung"
+	case WatchdogConnectionLost:
+		return "connection lost"
+	}
+	return fmt.Sprintf("WatchdogState(%d)", int(s))
+}
+
+// WatchdogAlert 状态变化时的告警，恢复时State为 WatchdogHealthy
+type WatchdogAlert struct {
+	State    WatchdogState
+	Previous WatchdogState
+	// 最后一次收到心跳的时间，还没有收到过时为启动看门狗的时间
+	LastHeartbeat time.Time
+	// 距离最后一次心跳的时间
+	Silence time.Duration
+}
+
+// WatchdogConfig 看门狗配置
+type WatchdogConfig struct {
+	// 心跳间隔，大于0时启动时写入 status_heartbeat_interval（按秒取整），Stop 时恢复原来的设置；为0时读取服务端当前的设置
+	Interval time.Duration
+	// 超过多久没有收到心跳视为服务端卡住，默认为3个心跳间隔
+	Timeout time.Duration
+	// 状态变化时的回调，在看门狗的协程中调用
+	OnAlert func(WatchdogAlert)
+	// 检查间隔和时间戳使用的时钟，默认为客户端的时钟
+	Clock clock.Clock
+}
+
+// Watchdog 监视状态心跳通知，区分服务端卡住（连接正常但没有心跳）和连接断开
+type Watchdog struct {
+	client  *MsmpClient
+	config  WatchdogConfig
+	timeout time.Duration
+	clock   clock.Clock
+
+	mutex         sync.Mutex
+	lastHeartbeat time.Time
+	state         WatchdogState
+	// 最近一次发现连接断开的时间和之后重新连接的时间
+	lostAt        time.Time
+	reconnectedAt time.Time
+	// 启动前的 status_heartbeat_interval，没有修改设置时为nil
+	previous json.RawMessage
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewWatchdog 创建看门狗，调用 Start 后开始监视
+func NewWatchdog(client *MsmpClient, config WatchdogConfig) *Watchdog {
+	w := &Watchdog{client: client, config: config, clock: config.Clock}
+	if w.clock == nil {
+		w.clock = client.Clock()
+	}
+	return w
+}
+
+// Start 配置或读取心跳间隔，订阅心跳通知并开始监视，ctx结束或调用 Stop 时停止
+// ctx结束后仍需调用 Stop 恢复修改过的心跳间隔
+func (w *Watchdog) Start(ctx context.Context) error {
+	// 先占用看门狗再修改设置，重复启动不会改动服务端
+	w.mutex.Lock()
+	if w.cancel != nil {
+		w.mutex.Unlock()
+		return errors.New("watchdog already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	w.cancel = cancel
+	w.stopped = stopped
+	w.mutex.Unlock()
+
+	interval, previous, err := w.heartbeatInterval(ctx)
+	if err != nil {
+		w.mutex.Lock()
+		if w.stopped == stopped {
+			w.cancel = nil
+		}
+		w.mutex.Unlock()
+		cancel()
+		close(stopped)
+		return fmt.Errorf("watchdog: %w", err)
+	}
+	timeout := w.config.Timeout
+	if timeout <= 0 {
+		timeout = 3 * interval
+	}
+
+	w.mutex.Lock()
+	if w.cancel == nil || w.stopped != stopped {
+		// 修改设置期间调用了 Stop，由这里恢复设置
+		w.mutex.Unlock()
+		cancel()
+		close(stopped)
+		return errors.Join(errors.New("watchdog stopped while starting"), w.restore(previous))
+	}
+	w.timeout = timeout
+	w.previous = previous
+	w.lastHeartbeat = w.clock.Now()
+	w.state = WatchdogHealthy
+	w.mutex.Unlock()
+
+	beat := make(chan struct{}, 1)
+	unsubscribe := w.client.Subscribe(NotificationServerStatus, func(*dto.MsmpNotification) {
+		w.mutex.Lock()
+		w.lastHeartbeat = w.clock.Now()
+		w.mutex.Unlock()
+		select {
+		case beat <- struct{}{}:
+		default:
+		}
+	})
+	go func() {
+		defer close(stopped)
+		defer unsubscribe()
+		ticker := w.clock.NewTicker(timeout / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+			case <-beat:
+			case <-w.client.ConnectionLost():
+				// 连接断开后 ConnectionLost 一直处于关闭状态，等待下一次检查
+				select {
+				case <-ticker.C():
+				case <-beat:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			w.check()
+		}
+	}()
+	return nil
+}
+
+// heartbeatInterval 按配置设置心跳间隔，或读取服务端当前的心跳间隔
+// 修改了设置时同时返回原来的值，用于停止时恢复
+func (w *Watchdog) heartbeatInterval(ctx context.Context) (time.Duration, json.RawMessage, error) {
+	raw, err := w.client.GetServerSetting(ctx, SettingStatusHeartbeatInterval)
+	if err != nil {
+		return 0, nil, err
+	}
+	if w.config.Interval > 0 {
+		seconds := int((w.config.Interval + time.Second - 1) / time.Second)
+		if _, err := w.client.SetServerSetting(ctx, SettingStatusHeartbeatInterval, seconds); err != nil {
+			return 0, nil, err
+		}
+		return time.Duration(seconds) * time.Second, raw, nil
+	}
+	var seconds int
+	if err := json.Unmarshal(raw, &seconds); err != nil {
+		return 0, nil, fmt.Errorf("invalid %s %s: %v", SettingStatusHeartbeatInterval, raw, err)
+	}
+	if seconds <= 0 {
+		return 0, nil, ErrHeartbeatDisabled
+	}
+	return time.Duration(seconds) * time.Second, nil, nil
+}
+
+// check 判断当前状态，状态变化时告警
+func (w *Watchdog) check() {
+	w.mutex.Lock()
+	now := w.clock.Now()
+	alert := WatchdogAlert{
+		Previous:      w.state,
+		LastHeartbeat: w.lastHeartbeat,
+		Silence:       now.Sub(w.lastHeartbeat),
+	}
+	switch {
+	case !w.client.IsConnected():
+		alert.State = WatchdogConnectionLost
+		w.lostAt = now
+		w.reconnectedAt = time.Time{}
+	case w.state == WatchdogConnectionLost && !w.lastHeartbeat.After(w.lostAt):
+		// 重新连接后收到心跳才算恢复，超时仍没有心跳时视为卡住
+		if w.reconnectedAt.IsZero() {
+			w.reconnectedAt = now
+		}
+		alert.State = WatchdogConnectionLost
+		if now.Sub(w.reconnectedAt) > w.timeout {
+			alert.State = WatchdogServerHung
+		}
+	case alert.Silence > w.timeout:
+		alert.State = WatchdogServerHung
+	default:
+		alert.State = WatchdogHealthy
+	}
+	changed := alert.State != w.state
+	w.state = alert.State
+	w.mutex.Unlock()
+	if changed && w.config.OnAlert != nil {
+		w.config.OnAlert(alert)
+	}
+}
+
+// Stop 停止监视，启动时修改了 status_heartbeat_interval 的恢复原来的设置
+func (w *Watchdog) Stop() error {
+	w.mutex.Lock()
+	cancel, stopped, previous := w.cancel, w.stopped, w.previous
+	w.cancel = nil
+	w.previous = nil
+	w.mutex.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-stopped
+	return w.restore(previous)
+}
+
+// restore 恢复启动前的心跳间隔，previous为nil时不做修改
+func (w *Watchdog) restore(previous json.RawMessage) error {
+	if previous == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := w.client.SetServerSetting(ctx, SettingStatusHeartbeatInterval, previous); err != nil {
+		return fmt.Errorf("watchdog: restore %s: %w", SettingStatusHeartbeatInterval, err)
+	}
+	return nil
+}
+
+// State 返回当前判断的状态
+func (w *Watchdog) State() WatchdogState {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.state
+}
+
+// LastHeartbeat 返回最后一次收到心跳的时间
+func (w *Watchdog) LastHeartbeat() time.Time {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.lastHeartbeat
+}